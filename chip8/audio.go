@@ -0,0 +1,159 @@
+package chip8
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	PatternBits        = 128            // bits in the XO-CHIP audio pattern buffer
+	DefaultFrequency   = 440.0          // buzzer tone in Hz
+	DefaultVolume      = 0.25           // buzzer volume, from 0 to 1
+	MaxBufferedSamples = SampleRate / 4 // samples kept when the frontend doesn't read them
+)
+
+// Shape of the buzzer tone.
+type Waveform int
+
+const (
+	SquareWave Waveform = iota
+	TriangleWave
+	SawtoothWave
+	SineWave
+)
+
+var Waveforms = map[string]Waveform{
+	"square":   SquareWave,
+	"triangle": TriangleWave,
+	"sawtooth": SawtoothWave,
+	"sine":     SineWave,
+}
+
+// Find a waveform by its name.
+func WaveformByName(name string) (Waveform, bool) {
+	waveform, ok := Waveforms[strings.ToLower(name)]
+	return waveform, ok
+}
+
+// Names of all waveforms, sorted.
+func WaveformNames() []string {
+	names := make([]string, 0, len(Waveforms))
+	for name := range Waveforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sound configures the buzzer synthesizer.
+//
+// While the sound timer is active, the emulator plays a tone of the Frequency
+// and Waveform, or the XO-CHIP audio pattern once one is loaded. Samples are
+// 16-bit mono PCM, generated in step with the timers by Advance and read by
+// the frontend with ReadSamples.
+type Sound struct {
+	Frequency  float64  // buzzer tone in Hz
+	Waveform   Waveform // buzzer tone shape
+	Volume     float64  // from 0 to 1
+	LowPass    float64  // low-pass filter cutoff in Hz softening the tone, 0 disables it
+	SampleRate int      // samples per second, 0 disables the synthesizer
+}
+
+func DefaultSound() Sound {
+	return Sound{
+		Frequency:  DefaultFrequency,
+		Waveform:   SquareWave,
+		Volume:     DefaultVolume,
+		SampleRate: SampleRate,
+	}
+}
+
+// XO-CHIP audio pattern playback rate in bits per second.
+func (emulator *Emulator) PatternRate() float64 {
+	return 4000 * math.Pow(2, (float64(emulator.Pitch)-64)/48)
+}
+
+// Move the generated samples to a buffer, returning how many were read.
+func (emulator *Emulator) ReadSamples(samples []int16) int {
+	n := copy(samples, emulator.samples)
+	emulator.samples = emulator.samples[:copy(emulator.samples, emulator.samples[n:])]
+	return n
+}
+
+// Number of generated samples not read yet.
+func (emulator *Emulator) BufferedSamples() int {
+	return len(emulator.samples)
+}
+
+// Generate the samples due up to lag nanoseconds before the end of
+// the time given to Advance.
+func (emulator *Emulator) generateSamples(lag int64) {
+	rate := int64(emulator.Sound.SampleRate)
+	if rate <= 0 {
+		return
+	}
+	for emulator.sampleClock-lag*rate >= second {
+		emulator.sampleClock -= second
+		emulator.samples = append(emulator.samples, emulator.sample())
+	}
+	if overflow := len(emulator.samples) - MaxBufferedSamples; overflow > 0 {
+		emulator.samples = emulator.samples[:copy(emulator.samples, emulator.samples[overflow:])]
+	}
+}
+
+// Next sample of the buzzer, silent when the sound timer is off.
+func (emulator *Emulator) sample() int16 {
+	level := emulator.level()
+	if cutoff := emulator.Sound.LowPass; cutoff > 0 {
+		// one-pole filter, also easing the buzzer in and out
+		alpha := 1 - math.Exp(-2*math.Pi*cutoff/float64(emulator.Sound.SampleRate))
+		emulator.filtered += alpha * (level - emulator.filtered)
+		level = emulator.filtered
+	}
+	return int16(level * math.Max(0, math.Min(1, emulator.Sound.Volume)) * math.MaxInt16)
+}
+
+// Buzzer level of the next sample, from -1 to 1.
+func (emulator *Emulator) level() float64 {
+	if emulator.ST == 0 {
+		return 0
+	}
+	rate := float64(emulator.Sound.SampleRate)
+
+	if emulator.PatternLoaded {
+		bit := int(emulator.patternPhase) % PatternBits
+		emulator.patternPhase = math.Mod(emulator.patternPhase+emulator.PatternRate()/rate, PatternBits)
+		if emulator.Pattern[bit/8]&(0x80>>(bit%8)) != 0 {
+			return 1
+		}
+		return -1
+	}
+
+	phase := emulator.phase
+	emulator.phase = math.Mod(emulator.phase+emulator.Sound.Frequency/rate, 1)
+	switch emulator.Sound.Waveform {
+	case TriangleWave:
+		return 1 - 4*math.Abs(phase-0.5)
+	case SawtoothWave:
+		return 2*phase - 1
+	case SineWave:
+		return math.Sin(2 * math.Pi * phase)
+	default:
+		if phase >= 0.5 {
+			return -1
+		}
+		return 1
+	}
+}
+
+// Notify the buzzer when it starts or stops.
+func (emulator *Emulator) setBuzzer(on bool) {
+	if on == emulator.buzzing {
+		return
+	}
+	emulator.buzzing = on
+	if emulator.Buzzer != nil {
+		emulator.Buzzer(on)
+	}
+}
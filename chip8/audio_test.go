@@ -0,0 +1,139 @@
+package chip8_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// 6002 - LD V0, 2; F018 - LD ST, V0; 1204 - JP 204
+var BuzzerROM = chip8.ROM{Data: []byte{0x60, 0x02, 0xF0, 0x18, 0x12, 0x04}}
+
+// Run the emulator for a number of frames, reading all the generated samples.
+func RecordFrames(t *testing.T, emulator *chip8.Emulator, frames int) []int16 {
+	var samples []int16
+	buffer := make([]int16, 1024)
+	for frame := 0; frame < frames; frame++ {
+		assert.NoError(t, emulator.Update())
+		for n := emulator.ReadSamples(buffer); n > 0; n = emulator.ReadSamples(buffer) {
+			samples = append(samples, buffer[:n]...)
+		}
+	}
+	return samples
+}
+
+func CountSound(samples []int16) int {
+	count := 0
+	for _, sample := range samples {
+		if sample != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestEmulator_Sound(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(BuzzerROM)
+
+	samples := RecordFrames(t, emulator, chip8.FPS)
+
+	assert.InDelta(t, chip8.SampleRate, len(samples), 1) // Update advances by a truncated 1/60s
+	// from the second instruction (1/480s) to the second tick (2/60s)
+	assert.InDelta(t, 44100*2/60-44100/480, CountSound(samples), 1)
+	assert.Equal(t, int16(8191), samples[100]) // DefaultVolume of the full scale
+	assert.Zero(t, samples[1500])
+}
+
+func TestEmulator_Sound_Waveform(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(BuzzerROM)
+	emulator.Sound.Waveform = chip8.SineWave
+	emulator.Sound.Volume = 1
+
+	samples := RecordFrames(t, emulator, 2)
+
+	max := 0
+	for _, sample := range samples {
+		if int(sample) > max {
+			max = int(sample)
+		}
+	}
+	assert.InDelta(t, math.MaxInt16, max, 10)
+	assert.Less(t, CountSound(samples), 1379) // the sine crosses zero
+}
+
+func TestEmulator_Sound_Pattern(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(IdleROM)
+	emulator.Pattern = [16]uint8{0xFF, 0x00}
+	emulator.PatternLoaded = true
+	emulator.ST = 10
+
+	samples := RecordFrames(t, emulator, 1)
+
+	// 4000 bits per second: each bit lasts about 11 samples
+	assert.Greater(t, samples[0], int16(0))
+	assert.Greater(t, samples[80], int16(0))
+	assert.Less(t, samples[100], int16(0))
+}
+
+func TestEmulator_Sound_Disabled(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(BuzzerROM)
+	emulator.Sound.SampleRate = 0
+
+	assert.Empty(t, RecordFrames(t, emulator, 10))
+}
+
+func TestEmulator_Sound_MaxBufferedSamples(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(IdleROM)
+
+	for frame := 0; frame < chip8.FPS; frame++ {
+		assert.NoError(t, emulator.Update())
+	}
+
+	assert.Equal(t, chip8.MaxBufferedSamples, emulator.BufferedSamples())
+}
+
+func TestEmulator_Buzzer(t *testing.T) {
+	var events []bool
+	emulator := chip8.NewEmulator(nil, func(on bool) { events = append(events, on) })
+	emulator.LoadROM(BuzzerROM)
+
+	for frame := 0; frame < 10; frame++ {
+		assert.NoError(t, emulator.Update())
+	}
+
+	assert.Equal(t, []bool{true, false}, events)
+}
+
+func MaxStep(samples []int16) int {
+	max := 0
+	for index := 1; index < len(samples); index++ {
+		step := int(samples[index]) - int(samples[index-1])
+		if step < 0 {
+			step = -step
+		}
+		if step > max {
+			max = step
+		}
+	}
+	return max
+}
+
+func TestEmulator_Sound_LowPass(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(BuzzerROM)
+	raw := MaxStep(RecordFrames(t, emulator, 10))
+
+	emulator.Reset()
+	emulator.Sound.LowPass = 2000
+	filtered := RecordFrames(t, emulator, 10)
+
+	assert.Less(t, MaxStep(filtered), raw/2)
+	assert.NotZero(t, CountSound(filtered))
+}
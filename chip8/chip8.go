@@ -1,34 +1,44 @@
 package chip8
 
 import (
-	_ "embed"
 	"encoding/binary"
-	"image"
 	"math"
+	"time"
 )
 
-//go:embed beep.wav
-var Beep []byte
-
 const (
-	MemorySize      = 4096 // 4KB of memory
-	InstructionSize = 2    // 2 bytes long instructions
+	MemorySize      = 65536 // 64KB of memory (XO-CHIP)
+	InstructionSize = 2     // 2 bytes long instructions
 )
 
 const (
+	FontAddress    = uint16(0x0000)
+	BigFontAddress = uint16(0x0050)
 	ProgramAddress = uint16(0x0200)
 )
 
 const (
 	Width          = 64
 	Height         = 32
+	HiResWidth     = 128
+	HiResHeight    = 64
 	FPS            = 60
 	CyclesPerFrame = 8
 	SampleRate     = 44100
 )
 
+const (
+	KeyCount = 16   // hexadecimal keypad
+	NoKey    = 0xFF // no key held while waiting on Fx0A
+)
+
+const (
+	PlaneCount   = 2  // XO-CHIP bitplanes
+	DefaultPitch = 64 // 4000Hz audio pattern playback
+)
+
 type KeyPressed func(key uint8) bool
-type SoundPlayer func(sound []byte) (func(), func())
+type Buzzer func(on bool)
 
 type ROM struct {
 	Name string
@@ -42,20 +52,58 @@ type Emulator struct {
 	DT         uint8             // delay timer
 	ST         uint8             // sound timer
 	Stack      *Stack            // very simple stack
-	Memory     [MemorySize]uint8 // 4KB of system RAM
+	Memory     [MemorySize]uint8 // 64KB of system RAM
+	RPL        [16]uint8         // SUPER-CHIP user flags
 	ROM        ROM               // game rom
-	Display    *image.RGBA       // display buffer
+	Display    *FrameBuffer      // display buffer
+	HiRes      bool              // SUPER-CHIP 128x64 mode
+	Plane      uint8             // XO-CHIP selected bitplanes
+	Pattern    [16]uint8         // XO-CHIP audio pattern buffer
+	Pitch      uint8             // XO-CHIP audio pattern pitch
+	Halted     bool              // program exited
+	Idle       bool              // program stuck jumping to itself
+	WaitingKey bool              // Fx0A is waiting for a key release
+	PressedKey uint8             // key held down while waiting, or NoKey
+	Quirks     Quirks            // instruction semantics
+	Seed       uint64            // random number generator seed
+	RNG        *RandomSource     // random number generator
 	KeyPressed KeyPressed        // input function
-	PlaySound  func()            // play sound effect
-	StopSound  func()            // stop sound effect
+	Error      error             // execution error that halted the program
+
+	InstructionsPerSecond int    // CPU speed
+	TimerRate             int    // DT and ST decrements per second
+	Sound                 Sound  // buzzer synthesizer settings
+	Buzzer                Buzzer // notified when the buzzer starts and stops, optional
+	PatternLoaded         bool   // the audio pattern replaces the buzzer tone
+
+	UnknownOpcodes  UnknownOpcodePolicy                                     // what to do with unknown opcodes
+	OnUnknownOpcode func(emulator *Emulator, instruction Instruction) error // unknown opcode callback
+	MachineCode     func(emulator *Emulator, nnn uint16) error              // 0nnn machine code routines
+
+	waitVBlank bool  // display wait quirk: drawing waits for the next timer tick
+	cycleClock int64 // scheduler time of the next instruction
+	timerClock int64 // scheduler time of the next timer tick
+
+	sampleClock  int64   // scheduler time of the next audio sample
+	samples      []int16 // generated audio samples not read yet
+	phase        float64 // buzzer tone phase, in cycles
+	patternPhase float64 // audio pattern playback position, in bits
+	filtered     float64 // low-pass filter output
+	buzzing      bool    // the buzzer is on
 }
 
-func NewEmulator(keyPressed KeyPressed, soundPlayer SoundPlayer) *Emulator {
+// Create an emulator. The buzzer function is optional: the sound
+// is also synthesized as samples, see Sound.
+func NewEmulator(keyPressed KeyPressed, buzzer Buzzer) *Emulator {
 	emulator := new(Emulator)
 	emulator.KeyPressed = keyPressed
-	emulator.PlaySound, emulator.StopSound = soundPlayer(Beep)
+	emulator.Buzzer = buzzer
+	emulator.Sound = DefaultSound()
 	emulator.Stack = NewStack()
-	emulator.Display = image.NewRGBA(image.Rect(0, 0, Width, Height))
+	emulator.InstructionsPerSecond = DefaultInstructionsPerSecond
+	emulator.TimerRate = DefaultTimerRate
+	emulator.Seed = uint64(time.Now().UnixNano())
+	emulator.RNG = NewRandomSource(emulator.Seed)
 	emulator.Reset()
 	return emulator
 }
@@ -67,19 +115,67 @@ func (emulator *Emulator) Reset() {
 	emulator.DT = 0
 	emulator.ST = 0
 	emulator.Memory = [MemorySize]uint8{}
+	emulator.Plane = 0b01
+	emulator.Pattern = [16]uint8{}
+	emulator.Pitch = DefaultPitch
+	emulator.Halted = false
+	emulator.Idle = false
+	emulator.Error = nil
+	emulator.WaitingKey = false
+	emulator.PressedKey = NoKey
+	emulator.waitVBlank = false
+	emulator.resetClocks()
 	emulator.Stack.Clear()
-	emulator.ClearScreen()
+	emulator.RNG.Seed(emulator.Seed)
+	emulator.SetResolution(false)
+	emulator.PatternLoaded = false
+	emulator.patternPhase = 0
+	emulator.setBuzzer(false)
 	emulator.LoadROM(emulator.ROM)
 	emulator.LoadFont()
 }
 
+// Seed the random number generator. Reset restarts the same random sequence.
+func (emulator *Emulator) SetSeed(seed uint64) {
+	emulator.Seed = seed
+	emulator.RNG.Seed(seed)
+}
+
 func (emulator *Emulator) LoadROM(rom ROM) {
 	emulator.ROM = rom
 	copy(emulator.Memory[ProgramAddress:], emulator.ROM.Data)
 }
 
+// Current display width, following the active resolution.
+func (emulator *Emulator) Width() int {
+	if emulator.HiRes {
+		return HiResWidth
+	}
+	return Width
+}
+
+// Current display height, following the active resolution.
+func (emulator *Emulator) Height() int {
+	if emulator.HiRes {
+		return HiResHeight
+	}
+	return Height
+}
+
+// Switch between the 64x32 and 128x64 display modes. All bitplanes are cleared.
+// The display generation keeps counting from the previous display.
+func (emulator *Emulator) SetResolution(hires bool) {
+	generation := uint64(0)
+	if emulator.Display != nil {
+		generation = emulator.Display.Generation + 1
+	}
+	emulator.HiRes = hires
+	emulator.Display = NewFrameBuffer(emulator.Width(), emulator.Height())
+	emulator.Display.Generation = generation
+}
+
 func (emulator *Emulator) LoadFont() {
-	copy(emulator.Memory[0:], []uint8{
+	copy(emulator.Memory[FontAddress:], []uint8{
 		0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
 		0x20, 0x60, 0x20, 0x20, 0x70, // 1
 		0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
@@ -97,118 +193,67 @@ func (emulator *Emulator) LoadFont() {
 		0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
 		0xF0, 0x80, 0xF0, 0x80, 0x80, // F
 	})
+	copy(emulator.Memory[BigFontAddress:], []uint8{
+		0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+		0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+		0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+		0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+		0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+		0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+		0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+		0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+		0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+		0x3C, 0x7E, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, // A
+		0xFC, 0xFE, 0xC3, 0xC3, 0xFE, 0xFE, 0xC3, 0xC3, 0xFE, 0xFC, // B
+		0x3C, 0x7E, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0x7E, 0x3C, // C
+		0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+	})
 }
 
-func (emulator *Emulator) Update() {
-	emulator.UpdateTimers()
-	for cycle := 0; cycle < CyclesPerFrame; cycle++ {
-		emulator.Cycle()
-	}
+// Run one frame (1/FPS seconds). Once the program is halted by an execution error,
+// the error is returned until the emulator is reset.
+func (emulator *Emulator) Update() error {
+	return emulator.Advance(time.Second / FPS)
 }
 
 func (emulator *Emulator) UpdateTimers() {
-	if emulator.ST != 0 {
-		emulator.PlaySound()
-	} else {
-		emulator.StopSound()
-	}
+	emulator.setBuzzer(emulator.ST != 0)
 	emulator.ST = uint8(math.Max(0, float64(emulator.ST)-1))
 	emulator.DT = uint8(math.Max(0, float64(emulator.DT)-1))
 }
 
-func (emulator *Emulator) Cycle() {
-	instruction := binary.BigEndian.Uint16(emulator.Memory[emulator.PC:])
-	if instruction == 0x0000 {
-		return
+// Execute one instruction.
+func (emulator *Emulator) Cycle() error {
+	if emulator.Halted {
+		return emulator.Error
 	}
+	pc := emulator.PC
+	if checkMemory(pc, InstructionSize) != nil {
+		return emulator.Halt(pc, 0x0000, ErrPCOutOfRange)
+	}
+	opcode := binary.BigEndian.Uint16(emulator.Memory[pc:])
 	emulator.PC += InstructionSize
 
-	nnn := instruction & 0x0FFF
-	n := uint8(instruction & 0x000F)
-	kk := uint8(instruction & 0x00FF)
-	x := uint8(instruction & 0x0F00 >> 8)
-	y := uint8(instruction & 0x00F0 >> 4)
-
-	switch instruction >> 12 & 0xF {
-	case 0x0:
-		switch instruction {
-		case 0x00E0: // 00E0 - CLS
-			emulator.ClearScreen()
-		case 0x00EE: // 00EE - RET
-			emulator.Return()
-		}
-	case 0x1: // 1nnn - JP addr
-		emulator.Jump(nnn)
-	case 0x2: // 2nnn - CALL addr
-		emulator.Call(nnn)
-	case 0x3: // 3xkk - SE Vx, byte
-		emulator.SkipEqualByte(x, kk)
-	case 0x4: // 4xkk - SNE Vx, byte
-		emulator.SkipNotEqualByte(x, kk)
-	case 0x5: // 5xy0 - SE Vx, Vy
-		emulator.SkipEqual(x, y)
-	case 0x6: // 6xkk - LD Vx, byte
-		emulator.LoadByte(x, kk)
-	case 0x7: // 7xkk - ADD Vx, byte
-		emulator.AddByte(x, kk)
-	case 0x8:
-		switch instruction & 0x000F {
-		case 0x0: // 8xy0 - LD Vx, Vy
-			emulator.Load(x, y)
-		case 0x1: // 8xy1 - OR Vx, Vy
-			emulator.Or(x, y)
-		case 0x2: // 8xy2 - AND Vx, Vy
-			emulator.And(x, y)
-		case 0x3: // 8xy3 - XOR Vx, Vy
-			emulator.Xor(x, y)
-		case 0x4: // 8xy4 - ADD Vx, Vy
-			emulator.Add(x, y)
-		case 0x5: // 8xy5 - SUB Vx, Vy
-			emulator.Sub(x, y)
-		case 0x6: // 8xy6 - SHR Vx {, Vy}
-			emulator.ShiftRight(x)
-		case 0x7: // 8xy7 - SUBN Vx, Vy
-			emulator.SubN(x, y)
-		case 0xE: // 8xyE - SHL Vx {, Vy}
-			emulator.ShiftLeft(x)
-		}
-	case 0x9: // 9xy0 - SNE Vx, Vy
-		emulator.SkipNotEqual(x, y)
-	case 0xA: // Annn - LD I, nnn
-		emulator.LoadI(nnn)
-	case 0xB: // Bnnn - JP V0, nnn
-		emulator.JumpV0(nnn)
-	case 0xC: // Cxkk - RND Vx, kk
-		emulator.Random(x, kk)
-	case 0xD: // Dxyn - DRW Vx, Vy, n
-		emulator.Draw(x, y, n)
-	case 0xE:
-		switch instruction & 0x00FF {
-		case 0x9E: // SKP Vx
-			emulator.SkipKeyPressed(x)
-		case 0xA1: // SKNP Vx
-			emulator.SkipKeyNotPressed(x)
-		}
-	case 0xF:
-		switch instruction & 0x00FF {
-		case 0x07: // LD Vx, DT
-			emulator.ReadDT(x)
-		case 0x0A: // LD Vx, K
-			emulator.ReadKey(x)
-		case 0x15: // LD DT, Vx
-			emulator.SetDT(x)
-		case 0x18: // LD ST, Vx
-			emulator.SetST(x)
-		case 0x1E: // ADD I, Vx
-			emulator.AddI(x)
-		case 0x29: // LD F, Vx
-			emulator.SetI(x)
-		case 0x33: // LD B, Vx
-			emulator.LoadBCD(x)
-		case 0x55: // LD [I], Vx
-			emulator.StoreRegisters(x)
-		case 0x65: // LD Vx, [I]
-			emulator.ReadRegisters(x)
-		}
+	instruction := Decode(opcode)
+	handler := instruction.Handler
+	if handler == nil {
+		handler = (*Emulator).UnknownOpcode
+	}
+	if err := handler(emulator, instruction); err != nil {
+		return emulator.Halt(pc, opcode, err)
 	}
+
+	// a jump to itself can only be left by a reset
+	emulator.Idle = emulator.PC == pc && !emulator.WaitingKey
+	return nil
+}
+
+// Stop the program after a failed instruction.
+func (emulator *Emulator) Halt(pc uint16, opcode uint16, cause error) error {
+	emulator.Halted = true
+	emulator.Error = &ExecutionError{PC: pc, Opcode: opcode, Err: cause}
+	return emulator.Error
 }
@@ -8,9 +8,7 @@ import (
 )
 
 func TestEmulator_Reset(t *testing.T) {
-	soundPlayer := func(sound []byte) (func(), func()) { return nil, nil }
-
-	emulator := chip8.NewEmulator(nil, soundPlayer)
+	emulator := chip8.NewEmulator(nil, nil)
 	emulator.V[0x03] = 0xFF
 	emulator.V[0x0F] = 0xBB
 
@@ -19,3 +17,111 @@ func TestEmulator_Reset(t *testing.T) {
 	assert.Equal(t, uint8(0x00), emulator.V[0x03])
 	assert.Equal(t, uint8(0x00), emulator.V[0x0F])
 }
+
+func TestEmulator_Cycle_StackUnderflow(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x00, 0xEE}})
+
+	err := emulator.Cycle()
+
+	var executionError *chip8.ExecutionError
+	assert.ErrorAs(t, err, &executionError)
+	assert.ErrorIs(t, err, chip8.ErrStackUnderflow)
+	assert.Equal(t, chip8.ProgramAddress, executionError.PC)
+	assert.Equal(t, uint16(0x00EE), executionError.Opcode)
+	assert.True(t, emulator.Halted)
+	assert.Equal(t, err, emulator.Update())
+}
+
+func TestEmulator_Cycle_MemoryOutOfRange(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{0xD0, 0x15}})
+	emulator.I = 0xFFFE
+
+	err := emulator.Cycle()
+
+	assert.ErrorIs(t, err, chip8.ErrMemoryOutOfRange)
+	assert.EqualError(t, err, "chip8: program halted at 0x200: memory out of range (opcode 0xD015)")
+}
+
+func TestEmulator_Cycle_PCOutOfRange(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.PC = chip8.MemorySize - 1
+
+	assert.ErrorIs(t, emulator.Cycle(), chip8.ErrPCOutOfRange)
+}
+
+func TestEmulator_Reset_Halted(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x00, 0xEE}})
+	emulator.Cycle()
+
+	emulator.Reset()
+
+	assert.False(t, emulator.Halted)
+	assert.NoError(t, emulator.Error)
+}
+
+func TestEmulator_Cycle_UnknownOpcode(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x60, 0x01, 0x5A, 0xB4}})
+
+	assert.NoError(t, emulator.Cycle())
+	err := emulator.Cycle()
+
+	assert.ErrorIs(t, err, chip8.ErrUnknownOpcode)
+	assert.EqualError(t, err, "chip8: program halted at 0x202: unknown opcode 0x5AB4")
+}
+
+func TestEmulator_Cycle_ZeroOpcode(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+
+	assert.ErrorIs(t, emulator.Cycle(), chip8.ErrUnknownOpcode)
+}
+
+func TestEmulator_Cycle_IgnoreUnknownOpcodes(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.UnknownOpcodes = chip8.IgnoreUnknownOpcodes
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x5A, 0xB4}})
+
+	assert.NoError(t, emulator.Cycle())
+	assert.Equal(t, chip8.ProgramAddress+2, emulator.PC)
+}
+
+func TestEmulator_Cycle_CallbackOnUnknownOpcodes(t *testing.T) {
+	var unknown []uint16
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.UnknownOpcodes = chip8.CallbackOnUnknownOpcodes
+	emulator.OnUnknownOpcode = func(emulator *chip8.Emulator, instruction chip8.Instruction) error {
+		unknown = append(unknown, instruction.Opcode)
+		return nil
+	}
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x5A, 0xB4, 0xE1, 0x00}})
+
+	assert.NoError(t, emulator.Cycle())
+	assert.NoError(t, emulator.Cycle())
+	assert.Equal(t, []uint16{0x5AB4, 0xE100}, unknown)
+}
+
+func TestEmulator_Cycle_MachineCode(t *testing.T) {
+	var routine uint16
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.MachineCode = func(emulator *chip8.Emulator, nnn uint16) error {
+		routine = nnn
+		return nil
+	}
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x03, 0x45}})
+
+	assert.NoError(t, emulator.Cycle())
+	assert.Equal(t, uint16(0x345), routine)
+}
+
+func TestEmulator_Cycle_Idle(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x60, 0x01, 0x12, 0x02}})
+
+	assert.NoError(t, emulator.Cycle())
+	assert.False(t, emulator.Idle)
+	assert.NoError(t, emulator.Cycle())
+	assert.True(t, emulator.Idle)
+}
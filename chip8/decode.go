@@ -0,0 +1,248 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+var ErrOpcodeInUse = errors.New("chip8: opcode range already in use")
+
+// Handler executes a decoded instruction. The program counter already points
+// to the next instruction.
+type Handler func(emulator *Emulator, instruction Instruction) error
+
+// Instruction is a decoded opcode.
+type Instruction struct {
+	Opcode   uint16
+	Mnemonic string  // e.g. DRW, empty when unknown
+	Syntax   string  // e.g. Vx, Vy, n
+	X        uint8   // -x-- register
+	Y        uint8   // --y- register
+	N        uint8   // ---n nibble
+	KK       uint8   // --kk byte
+	NNN      uint16  // -nnn address
+	Handler  Handler // nil when unknown
+}
+
+func (instruction Instruction) String() string {
+	if instruction.Mnemonic == "" {
+		return fmt.Sprintf("DW #%04X", instruction.Opcode)
+	}
+	if instruction.Syntax == "" {
+		return instruction.Mnemonic
+	}
+	return instruction.Mnemonic + " " + instruction.Operands()
+}
+
+var syntaxToken = regexp.MustCompile(`\w+`)
+
+// The operands, formatted by replacing the whole syntax tokens with their values.
+func (instruction Instruction) Operands() string {
+	return syntaxToken.ReplaceAllStringFunc(instruction.Syntax, func(token string) string {
+		switch token {
+		case "Vx":
+			return fmt.Sprintf("V%X", instruction.X)
+		case "Vy":
+			return fmt.Sprintf("V%X", instruction.Y)
+		case "nnn":
+			return fmt.Sprintf("#%03X", instruction.NNN)
+		case "kk":
+			return fmt.Sprintf("#%02X", instruction.KK)
+		case "n":
+			return fmt.Sprintf("%X", instruction.N)
+		case "x":
+			return fmt.Sprintf("%X", instruction.X)
+		}
+		return token
+	})
+}
+
+// An opcode matches a definition when opcode & Mask == Pattern.
+// Syntax describes the operands with the tokens Vx, Vy, nnn, kk, n and x.
+type definition struct {
+	Mask     uint16
+	Pattern  uint16
+	Mnemonic string
+	Syntax   string
+	Handler  Handler
+}
+
+func (def definition) Matches(opcode uint16) bool {
+	return opcode&def.Mask == def.Pattern
+}
+
+func (def definition) Overlaps(other definition) bool {
+	return (def.Pattern^other.Pattern)&def.Mask&other.Mask == 0
+}
+
+var instructionSet = []definition{
+	{0xFFF0, 0x00C0, "SCD", "n", func(emulator *Emulator, i Instruction) error { emulator.ScrollDown(i.N); return nil }},
+	{0xFFF0, 0x00D0, "SCU", "n", func(emulator *Emulator, i Instruction) error { emulator.ScrollUp(i.N); return nil }},
+	{0xFFFF, 0x00E0, "CLS", "", func(emulator *Emulator, i Instruction) error { emulator.ClearScreen(); return nil }},
+	{0xFFFF, 0x00EE, "RET", "", func(emulator *Emulator, i Instruction) error { return emulator.Return() }},
+	{0xFFFF, 0x00FB, "SCR", "", func(emulator *Emulator, i Instruction) error { emulator.ScrollRight(); return nil }},
+	{0xFFFF, 0x00FC, "SCL", "", func(emulator *Emulator, i Instruction) error { emulator.ScrollLeft(); return nil }},
+	{0xFFFF, 0x00FD, "EXIT", "", func(emulator *Emulator, i Instruction) error { emulator.Exit(); return nil }},
+	{0xFFFF, 0x00FE, "LOW", "", func(emulator *Emulator, i Instruction) error { emulator.SetResolution(false); return nil }},
+	{0xFFFF, 0x00FF, "HIGH", "", func(emulator *Emulator, i Instruction) error { emulator.SetResolution(true); return nil }},
+	{0xF000, 0x1000, "JP", "nnn", func(emulator *Emulator, i Instruction) error { emulator.Jump(i.NNN); return nil }},
+	{0xF000, 0x2000, "CALL", "nnn", func(emulator *Emulator, i Instruction) error { return emulator.Call(i.NNN) }},
+	{0xF000, 0x3000, "SE", "Vx, kk", func(emulator *Emulator, i Instruction) error { emulator.SkipEqualByte(i.X, i.KK); return nil }},
+	{0xF000, 0x4000, "SNE", "Vx, kk", func(emulator *Emulator, i Instruction) error { emulator.SkipNotEqualByte(i.X, i.KK); return nil }},
+	{0xF00F, 0x5000, "SE", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.SkipEqual(i.X, i.Y); return nil }},
+	{0xF00F, 0x5002, "SAVE", "Vx - Vy", func(emulator *Emulator, i Instruction) error { return emulator.StoreRange(i.X, i.Y) }},
+	{0xF00F, 0x5003, "LOAD", "Vx - Vy", func(emulator *Emulator, i Instruction) error { return emulator.ReadRange(i.X, i.Y) }},
+	{0xF000, 0x6000, "LD", "Vx, kk", func(emulator *Emulator, i Instruction) error { emulator.LoadByte(i.X, i.KK); return nil }},
+	{0xF000, 0x7000, "ADD", "Vx, kk", func(emulator *Emulator, i Instruction) error { emulator.AddByte(i.X, i.KK); return nil }},
+	{0xF00F, 0x8000, "LD", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.Load(i.X, i.Y); return nil }},
+	{0xF00F, 0x8001, "OR", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.Or(i.X, i.Y); return nil }},
+	{0xF00F, 0x8002, "AND", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.And(i.X, i.Y); return nil }},
+	{0xF00F, 0x8003, "XOR", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.Xor(i.X, i.Y); return nil }},
+	{0xF00F, 0x8004, "ADD", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.Add(i.X, i.Y); return nil }},
+	{0xF00F, 0x8005, "SUB", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.Sub(i.X, i.Y); return nil }},
+	{0xF00F, 0x8006, "SHR", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.ShiftRight(i.X, i.Y); return nil }},
+	{0xF00F, 0x8007, "SUBN", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.SubN(i.X, i.Y); return nil }},
+	{0xF00F, 0x800E, "SHL", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.ShiftLeft(i.X, i.Y); return nil }},
+	{0xF00F, 0x9000, "SNE", "Vx, Vy", func(emulator *Emulator, i Instruction) error { emulator.SkipNotEqual(i.X, i.Y); return nil }},
+	{0xF000, 0xA000, "LD", "I, nnn", func(emulator *Emulator, i Instruction) error { emulator.LoadI(i.NNN); return nil }},
+	{0xF000, 0xB000, "JP", "V0, nnn", func(emulator *Emulator, i Instruction) error { emulator.JumpV0(i.NNN); return nil }},
+	{0xF000, 0xC000, "RND", "Vx, kk", func(emulator *Emulator, i Instruction) error { emulator.Random(i.X, i.KK); return nil }},
+	{0xF000, 0xD000, "DRW", "Vx, Vy, n", func(emulator *Emulator, i Instruction) error { return emulator.Draw(i.X, i.Y, i.N) }},
+	{0xF0FF, 0xE09E, "SKP", "Vx", func(emulator *Emulator, i Instruction) error { emulator.SkipKeyPressed(i.X); return nil }},
+	{0xF0FF, 0xE0A1, "SKNP", "Vx", func(emulator *Emulator, i Instruction) error { emulator.SkipKeyNotPressed(i.X); return nil }},
+	{0xFFFF, 0xF000, "LD", "I, long", func(emulator *Emulator, i Instruction) error { return emulator.LoadLongI() }},
+	{0xF0FF, 0xF001, "PLANE", "x", func(emulator *Emulator, i Instruction) error { emulator.SelectPlane(i.X); return nil }},
+	{0xFFFF, 0xF002, "AUDIO", "", func(emulator *Emulator, i Instruction) error { return emulator.LoadPattern() }},
+	{0xF0FF, 0xF007, "LD", "Vx, DT", func(emulator *Emulator, i Instruction) error { emulator.ReadDT(i.X); return nil }},
+	{0xF0FF, 0xF00A, "LD", "Vx, K", func(emulator *Emulator, i Instruction) error { emulator.ReadKey(i.X); return nil }},
+	{0xF0FF, 0xF015, "LD", "DT, Vx", func(emulator *Emulator, i Instruction) error { emulator.SetDT(i.X); return nil }},
+	{0xF0FF, 0xF018, "LD", "ST, Vx", func(emulator *Emulator, i Instruction) error { emulator.SetST(i.X); return nil }},
+	{0xF0FF, 0xF01E, "ADD", "I, Vx", func(emulator *Emulator, i Instruction) error { emulator.AddI(i.X); return nil }},
+	{0xF0FF, 0xF029, "LD", "F, Vx", func(emulator *Emulator, i Instruction) error { emulator.SetI(i.X); return nil }},
+	{0xF0FF, 0xF030, "LD", "HF, Vx", func(emulator *Emulator, i Instruction) error { emulator.SetBigI(i.X); return nil }},
+	{0xF0FF, 0xF033, "LD", "B, Vx", func(emulator *Emulator, i Instruction) error { return emulator.LoadBCD(i.X) }},
+	{0xF0FF, 0xF03A, "PITCH", "Vx", func(emulator *Emulator, i Instruction) error { emulator.SetPitch(i.X); return nil }},
+	{0xF0FF, 0xF055, "LD", "[I], Vx", func(emulator *Emulator, i Instruction) error { return emulator.StoreRegisters(i.X) }},
+	{0xF0FF, 0xF065, "LD", "Vx, [I]", func(emulator *Emulator, i Instruction) error { return emulator.ReadRegisters(i.X) }},
+	{0xF0FF, 0xF075, "LD", "R, Vx", func(emulator *Emulator, i Instruction) error { emulator.StoreFlags(i.X); return nil }},
+	{0xF0FF, 0xF085, "LD", "Vx, R", func(emulator *Emulator, i Instruction) error { emulator.ReadFlags(i.X); return nil }},
+}
+
+// Built-in instruction index + 1 for every opcode, 0 when none matches.
+var builtinIndex = indexInstructions(instructionSet)
+
+func indexInstructions(definitions []definition) *[0x10000]uint8 {
+	index := new([0x10000]uint8)
+	for opcode := range index {
+		for position, def := range definitions {
+			if def.Matches(uint16(opcode)) {
+				index[opcode] = uint8(position + 1)
+				break
+			}
+		}
+	}
+	return index
+}
+
+// Instructions registered by Register, decoded when no built-in instruction matches.
+var (
+	customInstructions []definition
+	customMutex        sync.RWMutex
+)
+
+// Instructions decoded when no other instruction matches.
+var fallbackInstructions = []definition{
+	{0xF000, 0x0000, "SYS", "nnn", func(emulator *Emulator, i Instruction) error { return emulator.CallMachineCode(i) }},
+}
+
+// Decode an opcode into its instruction.
+//
+// Built-in instructions take precedence over the registered ones, which take
+// precedence over the generic 0nnn SYS call. Unknown opcodes are decoded with an empty mnemonic and a nil handler.
+func Decode(opcode uint16) Instruction {
+	instruction := Instruction{
+		Opcode: opcode,
+		X:      uint8(opcode & 0x0F00 >> 8),
+		Y:      uint8(opcode & 0x00F0 >> 4),
+		N:      uint8(opcode & 0x000F),
+		KK:     uint8(opcode & 0x00FF),
+		NNN:    opcode & 0x0FFF,
+	}
+	if position := builtinIndex[opcode]; position != 0 {
+		return instruction.with(instructionSet[position-1])
+	}
+	if def, ok := findCustom(opcode); ok {
+		return instruction.with(def)
+	}
+	for _, def := range fallbackInstructions {
+		if def.Matches(opcode) {
+			return instruction.with(def)
+		}
+	}
+	return instruction
+}
+
+func (instruction Instruction) with(def definition) Instruction {
+	instruction.Mnemonic = def.Mnemonic
+	instruction.Syntax = def.Syntax
+	instruction.Handler = def.Handler
+	return instruction
+}
+
+func findCustom(opcode uint16) (definition, bool) {
+	customMutex.RLock()
+	defer customMutex.RUnlock()
+	for _, def := range customInstructions {
+		if def.Matches(opcode) {
+			return def, true
+		}
+	}
+	return definition{}, false
+}
+
+// Register a handler for the opcodes where opcode & mask == pattern.
+//
+// Only opcodes not decoded by a built-in instruction reach the handler, so it can
+// implement SYS calls (0nnn) or fill the undefined ranges (e.g. 8xy8-8xyD).
+// The range can't overlap another registered one, nor be entirely decoded by
+// built-in instructions. The handlers are shared by
+// every emulator and can be registered while they run.
+func Register(mask uint16, pattern uint16, mnemonic string, syntax string, handler Handler) error {
+	def := definition{mask, pattern & mask, mnemonic, syntax, handler}
+	if def.builtin() {
+		return ErrOpcodeInUse
+	}
+	customMutex.Lock()
+	defer customMutex.Unlock()
+	for _, other := range customInstructions {
+		if def.Overlaps(other) {
+			return ErrOpcodeInUse
+		}
+	}
+	customInstructions = append(customInstructions, def)
+	return nil
+}
+
+// Whether every opcode of the range is decoded by a built-in instruction.
+func (def definition) builtin() bool {
+	for opcode := range builtinIndex {
+		if def.Matches(uint16(opcode)) && builtinIndex[opcode] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove the handler registered for exactly this mask and pattern.
+func Unregister(mask uint16, pattern uint16) {
+	customMutex.Lock()
+	defer customMutex.Unlock()
+	for index, def := range customInstructions {
+		if def.Mask == mask && def.Pattern == pattern&mask {
+			customInstructions = append(customInstructions[:index], customInstructions[index+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package chip8_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestDecode(t *testing.T) {
+	cases := map[uint16]string{
+		0x00E0: "CLS",
+		0x00C4: "SCD 4",
+		0x1234: "JP #234",
+		0x3A12: "SE VA, #12",
+		0x5122: "SAVE V1 - V2",
+		0x8AB6: "SHR VA, VB",
+		0xD125: "DRW V1, V2, 5",
+		0xF201: "PLANE 2",
+		0xF365: "LD V3, [I]",
+		0x8AB8: "DW #8AB8",
+	}
+
+	for opcode, text := range cases {
+		assert.Equal(t, text, chip8.Decode(opcode).String())
+	}
+}
+
+func TestDecode_AllInstructions(t *testing.T) {
+	cases := map[uint16]string{
+		0x00C4: "SCD 4", 0x00D4: "SCU 4", 0x00E0: "CLS", 0x00EE: "RET",
+		0x00FB: "SCR", 0x00FC: "SCL", 0x00FD: "EXIT", 0x00FE: "LOW", 0x00FF: "HIGH",
+		0x0123: "SYS #123", 0x1234: "JP #234", 0x2345: "CALL #345",
+		0x3A12: "SE VA, #12", 0x4A12: "SNE VA, #12", 0x5AB0: "SE VA, VB",
+		0x5AB2: "SAVE VA - VB", 0x5AB3: "LOAD VA - VB",
+		0x6A12: "LD VA, #12", 0x7A12: "ADD VA, #12",
+		0x8AB0: "LD VA, VB", 0x8AB1: "OR VA, VB", 0x8AB2: "AND VA, VB", 0x8AB3: "XOR VA, VB",
+		0x8AB4: "ADD VA, VB", 0x8AB5: "SUB VA, VB", 0x8AB6: "SHR VA, VB", 0x8AB7: "SUBN VA, VB",
+		0x8ABE: "SHL VA, VB", 0x9AB0: "SNE VA, VB",
+		0xA123: "LD I, #123", 0xB123: "JP V0, #123", 0xCA12: "RND VA, #12", 0xDAB5: "DRW VA, VB, 5",
+		0xEA9E: "SKP VA", 0xEAA1: "SKNP VA",
+		0xF000: "LD I, long", 0xF301: "PLANE 3", 0xF002: "AUDIO",
+		0xFA07: "LD VA, DT", 0xFA0A: "LD VA, K", 0xFA15: "LD DT, VA", 0xFA18: "LD ST, VA",
+		0xFA1E: "ADD I, VA", 0xFA29: "LD F, VA", 0xFA30: "LD HF, VA", 0xFA33: "LD B, VA",
+		0xFA3A: "PITCH VA", 0xFA55: "LD [I], VA", 0xFA65: "LD VA, [I]",
+		0xFA75: "LD R, VA", 0xFA85: "LD VA, R",
+	}
+
+	for opcode, text := range cases {
+		instruction := chip8.Decode(opcode)
+		assert.Equal(t, text, instruction.String(), "opcode %04X", opcode)
+		assert.NotNil(t, instruction.Handler, "opcode %04X", opcode)
+	}
+}
+
+func TestDecode_Operands(t *testing.T) {
+	instruction := chip8.Decode(0xD125)
+
+	assert.Equal(t, "DRW", instruction.Mnemonic)
+	assert.Equal(t, uint8(0x1), instruction.X)
+	assert.Equal(t, uint8(0x2), instruction.Y)
+	assert.Equal(t, uint8(0x5), instruction.N)
+	assert.Equal(t, "V1, V2, 5", instruction.Operands())
+	assert.NotNil(t, instruction.Handler)
+}
+
+func TestRegister(t *testing.T) {
+	// 8xy8 - MUL Vx, Vy
+	multiply := func(emulator *chip8.Emulator, instruction chip8.Instruction) error {
+		emulator.V[instruction.X] *= emulator.V[instruction.Y]
+		return nil
+	}
+	assert.NoError(t, chip8.Register(0xF00F, 0x8008, "MUL", "Vx, Vy", multiply))
+	defer chip8.Unregister(0xF00F, 0x8008)
+
+	assert.Equal(t, chip8.ErrOpcodeInUse, chip8.Register(0xF000, 0x8000, "EXT", "", multiply))
+	assert.Equal(t, "MUL V1, V2", chip8.Decode(0x8128).String())
+
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x81, 0x28}})
+	emulator.V[0x1] = 6
+	emulator.V[0x2] = 7
+
+	assert.NoError(t, emulator.Cycle())
+	assert.Equal(t, uint8(42), emulator.V[0x1])
+}
+
+func TestRegister_BuiltinRange(t *testing.T) {
+	jump := func(emulator *chip8.Emulator, instruction chip8.Instruction) error { return nil }
+
+	assert.Equal(t, chip8.ErrOpcodeInUse, chip8.Register(0xF000, 0x1000, "JMP", "nnn", jump))
+	assert.Equal(t, chip8.ErrOpcodeInUse, chip8.Register(0xFFFF, 0x00E0, "CLR", "", jump))
+	assert.Equal(t, "JP #234", chip8.Decode(0x1234).String())
+}
+
+func TestRegister_BuiltinPrecedence(t *testing.T) {
+	sys := func(emulator *chip8.Emulator, instruction chip8.Instruction) error { return nil }
+	assert.NoError(t, chip8.Register(0xF000, 0x0000, "SYS", "nnn", sys))
+	defer chip8.Unregister(0xF000, 0x0000)
+
+	assert.Equal(t, "CLS", chip8.Decode(0x00E0).String())
+	assert.Equal(t, "SYS #123", chip8.Decode(0x0123).String())
+}
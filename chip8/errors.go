@@ -0,0 +1,41 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStackOverflow    = errors.New("stack overflow")
+	ErrStackUnderflow   = errors.New("stack underflow")
+	ErrMemoryOutOfRange = errors.New("memory out of range")
+	ErrPCOutOfRange     = errors.New("program counter out of range")
+	ErrUnknownOpcode    = errors.New("unknown opcode")
+)
+
+// ExecutionError is returned when an instruction can't be executed.
+// The emulator stays halted until it is reset.
+type ExecutionError struct {
+	PC     uint16 // address of the failed instruction
+	Opcode uint16 // failed instruction
+	Err    error  // cause
+}
+
+func (err *ExecutionError) Error() string {
+	if err.Err == ErrUnknownOpcode {
+		return fmt.Sprintf("chip8: program halted at 0x%03X: unknown opcode 0x%04X", err.PC, err.Opcode)
+	}
+	return fmt.Sprintf("chip8: program halted at 0x%03X: %v (opcode 0x%04X)", err.PC, err.Err, err.Opcode)
+}
+
+func (err *ExecutionError) Unwrap() error {
+	return err.Err
+}
+
+// Check that size bytes starting at address are inside the memory.
+func checkMemory(address uint16, size int) error {
+	if int(address)+size > MemorySize {
+		return ErrMemoryOutOfRange
+	}
+	return nil
+}
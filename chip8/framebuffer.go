@@ -0,0 +1,100 @@
+package chip8
+
+import "image"
+
+// FrameBuffer is the display memory: one bit per pixel on each of the
+// PlaneCount bitplanes, rows packed most-significant bit first.
+type FrameBuffer struct {
+	Width      int
+	Height     int
+	Stride     int                 // bytes per row
+	Planes     [PlaneCount][]uint8 // packed pixels of each bitplane
+	Generation uint64              // incremented on every change
+}
+
+func NewFrameBuffer(width int, height int) *FrameBuffer {
+	fb := new(FrameBuffer)
+	fb.Width = width
+	fb.Height = height
+	fb.Stride = (width + 7) / 8
+	for plane := range fb.Planes {
+		fb.Planes[plane] = make([]uint8, fb.Stride*height)
+	}
+	return fb
+}
+
+func (fb *FrameBuffer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, fb.Width, fb.Height)
+}
+
+// Byte index and bit mask of a pixel inside a bitplane.
+func (fb *FrameBuffer) offset(x int, y int) (int, uint8) {
+	return y*fb.Stride + x/8, 0x80 >> (x % 8)
+}
+
+// Bitmask of the planes set at (x, y): bit 0 is plane 1, bit 1 is plane 2.
+func (fb *FrameBuffer) Pixel(x int, y int) uint8 {
+	pixel := uint8(0)
+	for plane := uint8(0); plane < PlaneCount; plane++ {
+		if fb.PlanePixel(plane, x, y) {
+			pixel |= 1 << plane
+		}
+	}
+	return pixel
+}
+
+// Whether the pixel at (x, y) is set on a bitplane (0 based).
+func (fb *FrameBuffer) PlanePixel(plane uint8, x int, y int) bool {
+	index, mask := fb.offset(x, y)
+	return fb.Planes[plane][index]&mask != 0
+}
+
+// XOR the pixel at (x, y) on a bitplane, returning whether it was erased.
+func (fb *FrameBuffer) Toggle(plane uint8, x int, y int) bool {
+	index, mask := fb.offset(x, y)
+	erased := fb.Planes[plane][index]&mask != 0
+	fb.Planes[plane][index] ^= mask
+	fb.Generation++
+	return erased
+}
+
+// Clear the bitplanes selected by the planes bitmask.
+func (fb *FrameBuffer) Clear(planes uint8) {
+	fb.Generation++
+	for plane := uint8(0); plane < PlaneCount; plane++ {
+		if planes&(1<<plane) == 0 {
+			continue
+		}
+		for index := range fb.Planes[plane] {
+			fb.Planes[plane][index] = 0x00
+		}
+	}
+}
+
+// Move the pixels of the bitplanes selected by the planes bitmask by (dx, dy).
+// The uncovered area is blank.
+func (fb *FrameBuffer) Scroll(planes uint8, dx int, dy int) {
+	fb.Generation++
+	for plane := uint8(0); plane < PlaneCount; plane++ {
+		if planes&(1<<plane) == 0 {
+			continue
+		}
+		previous := append([]uint8(nil), fb.Planes[plane]...)
+		for index := range fb.Planes[plane] {
+			fb.Planes[plane][index] = 0x00
+		}
+		for y := 0; y < fb.Height; y++ {
+			for x := 0; x < fb.Width; x++ {
+				sx, sy := x-dx, y-dy
+				if sx < 0 || sx >= fb.Width || sy < 0 || sy >= fb.Height {
+					continue
+				}
+				source, sourceMask := fb.offset(sx, sy)
+				if previous[source]&sourceMask != 0 {
+					index, mask := fb.offset(x, y)
+					fb.Planes[plane][index] |= mask
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package chip8_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestFrameBuffer_Toggle(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+
+	assert.False(t, fb.Toggle(0, 9, 3))
+	assert.Equal(t, uint8(0b01), fb.Pixel(9, 3))
+	assert.Equal(t, uint8(0x40), fb.Planes[0][3*fb.Stride+1])
+
+	assert.True(t, fb.Toggle(0, 9, 3))
+	assert.Equal(t, uint8(0b00), fb.Pixel(9, 3))
+}
+
+func TestFrameBuffer_Pixel_Planes(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+
+	fb.Toggle(0, 1, 1)
+	fb.Toggle(1, 1, 1)
+	fb.Toggle(1, 2, 1)
+
+	assert.Equal(t, uint8(0b11), fb.Pixel(1, 1))
+	assert.Equal(t, uint8(0b10), fb.Pixel(2, 1))
+}
+
+func TestFrameBuffer_Clear(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+	fb.Toggle(0, 1, 1)
+	fb.Toggle(1, 1, 1)
+
+	fb.Clear(0b01)
+
+	assert.Equal(t, uint8(0b10), fb.Pixel(1, 1))
+}
+
+func TestFrameBuffer_Scroll(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+	fb.Toggle(0, 1, 1)
+	fb.Toggle(1, 1, 1)
+
+	fb.Scroll(0b01, 4, 2)
+
+	assert.Equal(t, uint8(0b10), fb.Pixel(1, 1))
+	assert.Equal(t, uint8(0b01), fb.Pixel(5, 3))
+}
+
+func TestRenderer_Render(t *testing.T) {
+	white := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	black := color.RGBA{0x00, 0x00, 0x00, 0xFF}
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+	fb.Toggle(0, 5, 6)
+
+	renderer := chip8.NewRenderer(color.Palette{black, white})
+	frame := renderer.Render(fb)
+
+	assert.Equal(t, fb.Bounds(), frame.Bounds())
+	assert.Equal(t, white, frame.RGBAAt(5, 6))
+	assert.Equal(t, black, frame.RGBAAt(6, 6))
+}
+
+func TestFrameBuffer_Generation(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+
+	fb.Toggle(0, 1, 1)
+	assert.Equal(t, uint64(1), fb.Generation)
+	fb.Clear(0b01)
+	assert.Equal(t, uint64(2), fb.Generation)
+	fb.Scroll(0b01, 0, 1)
+	assert.Equal(t, uint64(3), fb.Generation)
+}
+
+func TestEmulator_SetResolution_Generation(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	generation := emulator.Display.Generation
+
+	emulator.SetResolution(true)
+
+	assert.Greater(t, emulator.Display.Generation, generation)
+}
+
+func TestRenderer_Render_Unchanged(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+	renderer := chip8.NewRenderer(chip8.DefaultPalette)
+
+	renderer.Render(fb)
+	assert.True(t, renderer.Changed)
+	renderer.Render(fb)
+	assert.False(t, renderer.Changed)
+
+	fb.Toggle(0, 0, 0)
+	renderer.Render(fb)
+	assert.True(t, renderer.Changed)
+
+	renderer.Palette = chip8.Palettes["amber"]
+	frame := renderer.Render(fb)
+	assert.True(t, renderer.Changed)
+	assert.Equal(t, chip8.Palettes["amber"][1], frame.At(0, 0))
+}
@@ -0,0 +1,93 @@
+package chip8
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+// Shortest GIF frame delay, in hundredths of a second. Most viewers
+// slow down frames with shorter delays.
+const MinGIFDelay = 2
+
+// GIFRecorder records the display frames into an animated GIF.
+//
+// Capture is called once per frame, at FPS. Unchanged frames extend the delay
+// of the previous image instead of being stored again, and changes faster than
+// MinGIFDelay replace the previous image. Every frame is drawn at the
+// high resolution size enlarged by Scale, so resolution changes don't resize
+// the animation.
+type GIFRecorder struct {
+	Palette color.Palette // colors indexed by the pixel planes bitmask
+	Scale   int           // enlargement of the 128x64 display
+	Frames  int           // frames captured
+
+	images     []*image.Paletted
+	starts     []int        // frame where each image starts
+	fb         *FrameBuffer // last captured frame buffer
+	generation uint64       // last captured frame buffer generation
+}
+
+func NewGIFRecorder(palette color.Palette, scale int) *GIFRecorder {
+	if scale < 1 {
+		scale = 1
+	}
+	recorder := new(GIFRecorder)
+	recorder.Palette = append(color.Palette{}, palette...)
+	recorder.Scale = scale
+	return recorder
+}
+
+// Add a frame of the display to the recording.
+func (recorder *GIFRecorder) Capture(fb *FrameBuffer) {
+	defer func() { recorder.Frames++ }()
+	if fb == recorder.fb && fb.Generation == recorder.generation {
+		return
+	}
+	recorder.fb, recorder.generation = fb, fb.Generation
+
+	img := recorder.paletted(fb)
+	last := len(recorder.images) - 1
+	switch {
+	case last >= 0 && bytes.Equal(img.Pix, recorder.images[last].Pix):
+	case last >= 0 && centiseconds(recorder.Frames)-centiseconds(recorder.starts[last]) < MinGIFDelay:
+		recorder.images[last] = img
+	default:
+		recorder.images = append(recorder.images, img)
+		recorder.starts = append(recorder.starts, recorder.Frames)
+	}
+}
+
+// Write the recorded frames as an animated GIF, looping forever.
+func (recorder *GIFRecorder) Encode(w io.Writer) error {
+	animation := new(gif.GIF)
+	animation.Image = recorder.images
+	animation.Delay = make([]int, len(recorder.images))
+	for index, start := range recorder.starts {
+		end := recorder.Frames
+		if index+1 < len(recorder.starts) {
+			end = recorder.starts[index+1]
+		}
+		animation.Delay[index] = centiseconds(end) - centiseconds(start)
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+func (recorder *GIFRecorder) paletted(fb *FrameBuffer) *image.Paletted {
+	width, height := HiResWidth*recorder.Scale, HiResHeight*recorder.Scale
+	img := image.NewPaletted(image.Rect(0, 0, width, height), recorder.Palette)
+	scale := recorder.Scale * HiResWidth / fb.Width
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Pix[y*img.Stride+x] = fb.Pixel(x/scale, y/scale)
+		}
+	}
+	return img
+}
+
+// Time of a frame in hundredths of a second.
+func centiseconds(frame int) int {
+	return (frame*100 + FPS/2) / FPS
+}
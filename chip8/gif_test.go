@@ -0,0 +1,85 @@
+package chip8_test
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestGIFRecorder_Deduplicate(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+	recorder := chip8.NewGIFRecorder(chip8.DefaultPalette, 1)
+
+	for frame := 0; frame < 6; frame++ {
+		recorder.Capture(fb)
+	}
+	fb.Toggle(0, 0, 0)
+	for frame := 0; frame < 3; frame++ {
+		recorder.Capture(fb)
+	}
+	fb.Toggle(0, 0, 0)
+	fb.Toggle(0, 0, 0) // same pixels, new generation
+	recorder.Capture(fb)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, recorder.Encode(&buffer))
+	animation, err := gif.DecodeAll(&buffer)
+	assert.NoError(t, err)
+
+	assert.Len(t, animation.Image, 2)
+	assert.Equal(t, []int{10, 7}, animation.Delay)
+	assert.Equal(t, image.Rect(0, 0, chip8.HiResWidth, chip8.HiResHeight), animation.Image[1].Bounds())
+	assert.Equal(t, chip8.DefaultPalette[1], animation.Image[1].At(1, 1))
+	assert.Equal(t, chip8.DefaultPalette[0], animation.Image[1].At(2, 0))
+}
+
+func TestGIFRecorder_MinDelay(t *testing.T) {
+	fb := chip8.NewFrameBuffer(chip8.Width, chip8.Height)
+	recorder := chip8.NewGIFRecorder(chip8.DefaultPalette, 1)
+
+	for frame := 0; frame < 6; frame++ {
+		fb.Toggle(0, frame, 0)
+		recorder.Capture(fb)
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, recorder.Encode(&buffer))
+	animation, err := gif.DecodeAll(&buffer)
+	assert.NoError(t, err)
+
+	for _, delay := range animation.Delay {
+		assert.GreaterOrEqual(t, delay, chip8.MinGIFDelay)
+	}
+	total := 0
+	for _, delay := range animation.Delay {
+		total += delay
+	}
+	assert.Equal(t, 10, total)
+	assert.Equal(t, chip8.DefaultPalette[1], animation.Image[len(animation.Image)-1].At(10, 0))
+}
+
+func TestGIFRecorder_Headless(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(chip8.ROM{Data: []byte{
+		0x00, 0xE0, // CLS
+		0xD0, 0x15, // DRW V0, V1, 5
+		0x12, 0x02, // JP 0x202
+	}})
+	recorder := chip8.NewGIFRecorder(chip8.DefaultPalette, 2)
+
+	for frame := 0; frame < chip8.FPS; frame++ {
+		assert.NoError(t, emulator.Update())
+		recorder.Capture(emulator.Display)
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, recorder.Encode(&buffer))
+	animation, err := gif.DecodeAll(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, chip8.FPS, recorder.Frames)
+	assert.Equal(t, image.Rect(0, 0, chip8.HiResWidth*2, chip8.HiResHeight*2), animation.Image[0].Bounds())
+}
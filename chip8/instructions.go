@@ -1,22 +1,38 @@
 package chip8
 
 import (
-	"image"
-	"image/color"
-	"image/draw"
-	"math/rand"
+	"encoding/binary"
 )
 
 // Clear the display.
+//
+// Only the selected bitplanes (XO-CHIP) are cleared.
 func (emulator *Emulator) ClearScreen() {
-	draw.Draw(emulator.Display, emulator.Display.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	emulator.Display.Clear(emulator.Plane)
+}
+
+// Skip the next instruction.
+//
+// The program counter is increased by 2, or by 4 when the next instruction
+// is the 4 bytes long F000 nnnn (XO-CHIP).
+func (emulator *Emulator) SkipNext() {
+	if checkMemory(emulator.PC, InstructionSize) == nil &&
+		binary.BigEndian.Uint16(emulator.Memory[emulator.PC:]) == 0xF000 {
+		emulator.PC += InstructionSize
+	}
+	emulator.PC += InstructionSize
 }
 
 // Return from a subroutine.
 //
 // The interpreter sets the program counter to the address at the top of the stack.
-func (emulator *Emulator) Return() {
-	emulator.PC = emulator.Stack.Pop()
+func (emulator *Emulator) Return() error {
+	address, err := emulator.Stack.Pop()
+	if err != nil {
+		return err
+	}
+	emulator.PC = address
+	return nil
 }
 
 // Jump to location nnn.
@@ -30,9 +46,12 @@ func (emulator *Emulator) Jump(nnn uint16) {
 //
 // Puts the current PC on the top of the stack.
 // The PC is then set to nnn.
-func (emulator *Emulator) Call(nnn uint16) {
-	emulator.Stack.Push(emulator.PC)
+func (emulator *Emulator) Call(nnn uint16) error {
+	if err := emulator.Stack.Push(emulator.PC); err != nil {
+		return err
+	}
 	emulator.PC = nnn
+	return nil
 }
 
 // Skip next instruction if Vx = kk.
@@ -41,7 +60,7 @@ func (emulator *Emulator) Call(nnn uint16) {
 // increments the program counter by 2.
 func (emulator *Emulator) SkipEqualByte(x uint8, kk uint8) {
 	if emulator.V[x] == kk {
-		emulator.PC += InstructionSize
+		emulator.SkipNext()
 	}
 }
 
@@ -51,7 +70,7 @@ func (emulator *Emulator) SkipEqualByte(x uint8, kk uint8) {
 // increments the program counter by 2.
 func (emulator *Emulator) SkipNotEqualByte(x uint8, kk uint8) {
 	if emulator.V[x] != kk {
-		emulator.PC += InstructionSize
+		emulator.SkipNext()
 	}
 }
 
@@ -61,7 +80,7 @@ func (emulator *Emulator) SkipNotEqualByte(x uint8, kk uint8) {
 // increments the program counter by 2.
 func (emulator *Emulator) SkipEqual(x uint8, y uint8) {
 	if emulator.V[x] == emulator.V[y] {
-		emulator.PC += InstructionSize
+		emulator.SkipNext()
 	}
 }
 
@@ -91,8 +110,10 @@ func (emulator *Emulator) Load(x uint8, y uint8) {
 // Performs a bitwise OR on the values of Vx and Vy, then stores the result in Vx.
 // A bitwise OR compares the corrseponding bits from two values, and if either bit is 1,
 // then the same bit in the result is also 1. Otherwise, it is 0.
+// With the VF reset quirk, VF is set to 0.
 func (emulator *Emulator) Or(x uint8, y uint8) {
 	emulator.V[x] |= emulator.V[y]
+	emulator.ResetVF()
 }
 
 // Set Vx = Vx AND Vy.
@@ -100,8 +121,10 @@ func (emulator *Emulator) Or(x uint8, y uint8) {
 // Performs a bitwise AND on the values of Vx and Vy, then stores the result in Vx.
 // A bitwise AND compares the corrseponding bits from two values, and if both bits are 1,
 // then the same bit in the result is also 1. Otherwise, it is 0.
+// With the VF reset quirk, VF is set to 0.
 func (emulator *Emulator) And(x uint8, y uint8) {
 	emulator.V[x] &= emulator.V[y]
+	emulator.ResetVF()
 }
 
 // Set Vx = Vx XOR Vy.
@@ -109,8 +132,19 @@ func (emulator *Emulator) And(x uint8, y uint8) {
 // Performs a bitwise exclusive OR on the values of Vx and Vy, then stores the result in Vx.
 // An exclusive OR compares the corrseponding bits from two values, and if the bits are not
 // both the same, then the corresponding bit in the result is set to 1. Otherwise, it is 0.
+// With the VF reset quirk, VF is set to 0.
 func (emulator *Emulator) Xor(x uint8, y uint8) {
 	emulator.V[x] ^= emulator.V[y]
+	emulator.ResetVF()
+}
+
+// Set VF = 0 when the VF reset quirk is enabled.
+//
+// The COSMAC VIP logic instructions leave VF cleared as a side effect.
+func (emulator *Emulator) ResetVF() {
+	if emulator.Quirks.VFReset {
+		emulator.V[0xF] = 0x00
+	}
 }
 
 // Set Vx = Vx + Vy, set VF = carry.
@@ -136,10 +170,14 @@ func (emulator *Emulator) Sub(x uint8, y uint8) {
 // Set Vx = Vx SHR 1.
 //
 // If the least-significant bit of Vx is 1, then VF is set to 1, otherwise 0.
-// Then Vx is divided by 2.
-func (emulator *Emulator) ShiftRight(x uint8) {
-	emulator.V[0xF] = emulator.V[x] & 0b00000001
+// Then Vx is divided by 2. With the shift quirk, Vy is shifted into Vx instead.
+func (emulator *Emulator) ShiftRight(x uint8, y uint8) {
+	if emulator.Quirks.ShiftVy {
+		emulator.V[x] = emulator.V[y]
+	}
+	flag := emulator.V[x] & 0b00000001
 	emulator.V[x] >>= 1
+	emulator.V[0xF] = flag
 }
 
 // Set Vx = Vy - Vx, set VF = NOT borrow.
@@ -154,10 +192,14 @@ func (emulator *Emulator) SubN(x uint8, y uint8) {
 // Set Vx = Vx SHL 1.
 //
 // If the most-significant bit of Vx is 1, then VF is set to 1, otherwise to 0.
-// Then Vx is multiplied by 2.
-func (emulator *Emulator) ShiftLeft(x uint8) {
-	emulator.V[0xF] = (emulator.V[x] & 0b10000000) >> 7
+// Then Vx is multiplied by 2. With the shift quirk, Vy is shifted into Vx instead.
+func (emulator *Emulator) ShiftLeft(x uint8, y uint8) {
+	if emulator.Quirks.ShiftVy {
+		emulator.V[x] = emulator.V[y]
+	}
+	flag := (emulator.V[x] & 0b10000000) >> 7
 	emulator.V[x] <<= 1
+	emulator.V[0xF] = flag
 }
 
 // Skip next instruction if Vx != Vy.
@@ -166,7 +208,7 @@ func (emulator *Emulator) ShiftLeft(x uint8) {
 // the program counter is increased by 2.
 func (emulator *Emulator) SkipNotEqual(x uint8, y uint8) {
 	if emulator.V[x] != emulator.V[y] {
-		emulator.PC += InstructionSize
+		emulator.SkipNext()
 	}
 }
 
@@ -180,8 +222,13 @@ func (emulator *Emulator) LoadI(nnn uint16) {
 // Jump to location nnn + V0.
 //
 // The program counter is set to nnn plus the value of V0.
+// With the jump quirk (Bxnn), the value of Vx is used instead of V0.
 func (emulator *Emulator) JumpV0(nnn uint16) {
-	emulator.PC = nnn + uint16(emulator.V[0])
+	register := uint8(0)
+	if emulator.Quirks.JumpVx {
+		register = uint8(nnn >> 8)
+	}
+	emulator.PC = nnn + uint16(emulator.V[register])
 }
 
 // Set Vx = random byte AND kk.
@@ -189,7 +236,7 @@ func (emulator *Emulator) JumpV0(nnn uint16) {
 // The interpreter generates a random number from 0 to 255, which is then ANDed with the value kk.
 // The results are stored in Vx. See instruction 8xy2 for more information on AND.
 func (emulator *Emulator) Random(x uint8, kk uint8) {
-	emulator.V[x] = uint8(rand.Intn(0xFF)) & kk
+	emulator.V[x] = emulator.RNG.Byte() & kk
 }
 
 // Display n-byte sprite starting at memory location I at (Vx, Vy), set VF = collision.
@@ -198,51 +245,128 @@ func (emulator *Emulator) Random(x uint8, kk uint8) {
 // These bytes are then displayed as sprites on screen at coordinates (Vx, Vy).
 // Sprites are XORed onto the existing screen. If this causes any pixels to be erased,
 // VF is set to 1, otherwise it is set to 0. If the sprite is positioned so part of it
-// is outside the coordinates of the display, it wraps around to the opposite side of the screen.
-func (emulator *Emulator) Draw(x uint8, y uint8, n uint8) {
-	x = emulator.V[x]
-	y = emulator.V[y]
-	width := uint8(8)
-	height := n
+// is outside the coordinates of the display, it wraps around to the opposite side of the screen,
+// or is clipped when the clipping quirk is enabled.
+// When n is 0, a 16x16 sprite (SUPER-CHIP) is read as 16 rows of 2 bytes.
+// When more than one bitplane is selected (XO-CHIP), the sprite data for each plane
+// follows the previous one in memory.
+func (emulator *Emulator) Draw(x uint8, y uint8, n uint8) error {
+	screenWidth, screenHeight := emulator.Width(), emulator.Height()
+	left := int(emulator.V[x]) % screenWidth
+	top := int(emulator.V[y]) % screenHeight
+	width, height := 8, int(n)
+	if n == 0 {
+		width, height = 16, 16
+	}
+	address := emulator.I
+
+	planes := 0
+	for plane := uint8(0); plane < PlaneCount; plane++ {
+		if emulator.Plane&(1<<plane) != 0 {
+			planes++
+		}
+	}
+	if err := checkMemory(address, planes*height*width/8); err != nil {
+		return err
+	}
 
 	emulator.V[0xF] = 0x00 // clean collision flag
 
-	for yline := uint8(0); yline < height; yline++ {
-		sprite := emulator.Memory[emulator.I+uint16(yline)]
+	if emulator.Quirks.DisplayWait {
+		emulator.waitVBlank = true
+	}
+
+	for plane := uint8(0); plane < PlaneCount; plane++ {
+		if emulator.Plane&(1<<plane) == 0 {
+			continue
+		}
 
-		for xline := uint8(0); xline < width; xline++ {
-			if (sprite & 0b10000000) != 0x00 {
-				px, py := int(x+xline)%Width, int(y+yline)%Height
-				index := emulator.Display.PixOffset(px, py) + 1 // color offset: 0:red, 1:green, 2:blue
+		for yline := 0; yline < height; yline++ {
+			sprite := uint16(emulator.Memory[address]) << 8
+			address++
+			if width == 16 {
+				sprite |= uint16(emulator.Memory[address])
+				address++
+			}
 
-				if emulator.Display.Pix[index] != 0x00 {
+			for xline := 0; xline < width; xline++ {
+				bit := sprite & 0x8000
+				sprite <<= 1
+				if bit == 0x00 {
+					continue
+				}
+				px, py := left+xline, top+yline
+				if emulator.Quirks.Clipping && (px >= screenWidth || py >= screenHeight) {
+					continue
+				}
+				if emulator.Display.Toggle(plane, px%screenWidth, py%screenHeight) {
 					emulator.V[0xF] = 0x01 // collision
 				}
-
-				emulator.Display.Pix[index] ^= 0xFF
 			}
-			sprite <<= 1
 		}
 	}
+	return nil
+}
+
+// Scroll the display down n pixels.
+//
+// The rows shifted in at the top are blank.
+func (emulator *Emulator) ScrollDown(n uint8) {
+	emulator.scroll(0, int(n))
+}
+
+// Scroll the display up n pixels (XO-CHIP).
+//
+// The rows shifted in at the bottom are blank.
+func (emulator *Emulator) ScrollUp(n uint8) {
+	emulator.scroll(0, -int(n))
+}
+
+// Scroll the display right by 4 pixels.
+//
+// The columns shifted in at the left are blank.
+func (emulator *Emulator) ScrollRight() {
+	emulator.scroll(4, 0)
+}
+
+// Scroll the display left by 4 pixels.
+//
+// The columns shifted in at the right are blank.
+func (emulator *Emulator) ScrollLeft() {
+	emulator.scroll(-4, 0)
+}
+
+// Move every pixel of the selected bitplanes by (dx, dy), blanking the uncovered area.
+func (emulator *Emulator) scroll(dx int, dy int) {
+	emulator.Display.Scroll(emulator.Plane, dx, dy)
+}
+
+// Exit the interpreter.
+//
+// The program stops running; the emulator stays halted until it is reset.
+func (emulator *Emulator) Exit() {
+	emulator.Halted = true
 }
 
 // Skip next instruction if key with the value of Vx is pressed.
 //
 // Checks the keyboard, and if the key corresponding to the value of Vx
-// is currently in the down position, PC is increased by 2.
+// is currently in the down position, PC is increased by 2. Only the low
+// nibble of Vx selects the key, like the COSMAC VIP.
 func (emulator *Emulator) SkipKeyPressed(x uint8) {
-	if emulator.KeyPressed(emulator.V[x]) {
-		emulator.PC += InstructionSize
+	if emulator.KeyPressed(emulator.V[x] & 0xF) {
+		emulator.SkipNext()
 	}
 }
 
 // Skip next instruction if key with the value of Vx is not pressed.
 //
 // Checks the keyboard, and if the key corresponding to the value of Vx
-// is currently in the up position, PC is increased by 2.
+// is currently in the up position, PC is increased by 2. Only the low
+// nibble of Vx selects the key.
 func (emulator *Emulator) SkipKeyNotPressed(x uint8) {
-	if !emulator.KeyPressed(emulator.V[x]) {
-		emulator.PC += InstructionSize
+	if !emulator.KeyPressed(emulator.V[x] & 0xF) {
+		emulator.SkipNext()
 	}
 }
 
@@ -255,16 +379,25 @@ func (emulator *Emulator) ReadDT(x uint8) {
 
 // Wait for a key press, store the value of the key in Vx.
 //
-// All execution stops until a key is pressed, then the value of that key is stored in Vx.
+// All execution stops until a key is pressed and released, then the value of that key
+// is stored in Vx, as the COSMAC VIP does. While waiting, the instruction is executed
+// again and the timers keep running.
 func (emulator *Emulator) ReadKey(x uint8) {
-	for {
-		for key := uint8(0); key < 16; key++ {
+	if emulator.PressedKey == NoKey {
+		for key := uint8(0); key < KeyCount; key++ {
 			if emulator.KeyPressed(key) {
-				emulator.V[x] = key
-				return
+				emulator.PressedKey = key
+				break
 			}
 		}
+	} else if !emulator.KeyPressed(emulator.PressedKey) {
+		emulator.V[x] = emulator.PressedKey
+		emulator.PressedKey = NoKey
+		emulator.WaitingKey = false
+		return
 	}
+	emulator.WaitingKey = true
+	emulator.PC -= InstructionSize // wait on this instruction
 }
 
 // Set delay timer = Vx.
@@ -296,26 +429,149 @@ func (emulator *Emulator) SetI(x uint8) {
 	emulator.I = uint16(emulator.V[x]) * 5
 }
 
+// Set I = nnnn (XO-CHIP).
+//
+// The value of register I is set to the 16-bit address stored in the 2 bytes
+// following the instruction, and the program counter skips over them.
+func (emulator *Emulator) LoadLongI() error {
+	if err := checkMemory(emulator.PC, InstructionSize); err != nil {
+		return ErrPCOutOfRange
+	}
+	emulator.I = binary.BigEndian.Uint16(emulator.Memory[emulator.PC:])
+	emulator.PC += InstructionSize
+	return nil
+}
+
+// Select the bitplanes n used by drawing, clearing and scrolling (XO-CHIP).
+//
+// Plane 1 is the classic display, plane 2 the additional one; 3 selects both.
+func (emulator *Emulator) SelectPlane(n uint8) {
+	emulator.Plane = n & 0b11
+}
+
+// Load the 16-byte audio pattern buffer from memory starting at location I (XO-CHIP).
+//
+// The pattern is played while the sound timer is active.
+func (emulator *Emulator) LoadPattern() error {
+	if err := checkMemory(emulator.I, len(emulator.Pattern)); err != nil {
+		return err
+	}
+	copy(emulator.Pattern[:], emulator.Memory[emulator.I:])
+	emulator.PatternLoaded = true
+	return nil
+}
+
+// Set the audio pattern playback pitch = Vx (XO-CHIP).
+//
+// The playback rate is 4000*2^((Vx-64)/48) bits per second.
+func (emulator *Emulator) SetPitch(x uint8) {
+	emulator.Pitch = emulator.V[x]
+}
+
+// Set I = location of the 10-byte sprite for digit Vx.
+//
+// The value of I is set to the location for the SUPER-CHIP large hexadecimal sprite
+// corresponding to the value of Vx.
+func (emulator *Emulator) SetBigI(x uint8) {
+	emulator.I = BigFontAddress + uint16(emulator.V[x]&0x0F)*10
+}
+
 // Store BCD representation of Vx in memory locations I, I+1, and I+2.
 //
 // The interpreter takes the decimal value of Vx, and places the hundreds digit in memory
 // at location in I, the tens digit at location I+1, and the ones digit at location I+2.
-func (emulator *Emulator) LoadBCD(x uint8) {
+func (emulator *Emulator) LoadBCD(x uint8) error {
+	if err := checkMemory(emulator.I, 3); err != nil {
+		return err
+	}
 	emulator.Memory[emulator.I] = emulator.V[x] / 100          // hundreds digit
 	emulator.Memory[emulator.I+1] = (emulator.V[x] % 100) / 10 // tens digit
 	emulator.Memory[emulator.I+2] = emulator.V[x] % 10         // ones digit
+	return nil
 }
 
 // Store registers V0 through Vx in memory starting at location I.
 //
 // The interpreter copies the values of registers V0 through Vx into memory, starting at the address in I.
-func (emulator *Emulator) StoreRegisters(x uint8) {
+// With the load/store quirk, I is set to I + x + 1.
+func (emulator *Emulator) StoreRegisters(x uint8) error {
+	if err := checkMemory(emulator.I, int(x)+1); err != nil {
+		return err
+	}
 	copy(emulator.Memory[emulator.I:], emulator.V[:x+1])
+	if emulator.Quirks.LoadStoreIncrementI {
+		emulator.I += uint16(x) + 1
+	}
+	return nil
 }
 
 // Read registers V0 through Vx from memory starting at location I.
 //
 // The interpreter reads values from memory starting at location I into registers V0 through Vx.
-func (emulator *Emulator) ReadRegisters(x uint8) {
+// With the load/store quirk, I is set to I + x + 1.
+func (emulator *Emulator) ReadRegisters(x uint8) error {
+	if err := checkMemory(emulator.I, int(x)+1); err != nil {
+		return err
+	}
 	copy(emulator.V[:x+1], emulator.Memory[emulator.I:])
+	if emulator.Quirks.LoadStoreIncrementI {
+		emulator.I += uint16(x) + 1
+	}
+	return nil
+}
+
+// Store registers V0 through Vx in the RPL user flags.
+//
+// The interpreter copies the values of registers V0 through Vx into the persistent RPL flags.
+func (emulator *Emulator) StoreFlags(x uint8) {
+	copy(emulator.RPL[:], emulator.V[:x+1])
+}
+
+// Read registers V0 through Vx from the RPL user flags.
+//
+// The interpreter reads values from the persistent RPL flags into registers V0 through Vx.
+func (emulator *Emulator) ReadFlags(x uint8) {
+	copy(emulator.V[:x+1], emulator.RPL[:])
+}
+
+// Store registers Vx through Vy in memory starting at location I (XO-CHIP).
+//
+// The registers are stored in the given order, which may be descending. I is not modified.
+func (emulator *Emulator) StoreRange(x uint8, y uint8) error {
+	registers := registerRange(x, y)
+	if err := checkMemory(emulator.I, len(registers)); err != nil {
+		return err
+	}
+	for i, register := range registers {
+		emulator.Memory[emulator.I+uint16(i)] = emulator.V[register]
+	}
+	return nil
+}
+
+// Read registers Vx through Vy from memory starting at location I (XO-CHIP).
+//
+// The registers are read in the given order, which may be descending. I is not modified.
+func (emulator *Emulator) ReadRange(x uint8, y uint8) error {
+	registers := registerRange(x, y)
+	if err := checkMemory(emulator.I, len(registers)); err != nil {
+		return err
+	}
+	for i, register := range registers {
+		emulator.V[register] = emulator.Memory[emulator.I+uint16(i)]
+	}
+	return nil
+}
+
+// Register indexes from x to y, inclusive, in either direction.
+func registerRange(x uint8, y uint8) []uint8 {
+	registers := []uint8{x}
+	for x != y {
+		if x < y {
+			x++
+		} else {
+			x--
+		}
+		registers = append(registers, x)
+	}
+	return registers
 }
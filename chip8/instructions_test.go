@@ -1 +1,336 @@
 package chip8_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func NewTestEmulator(quirks chip8.Quirks) *chip8.Emulator {
+	keyPressed := func(key uint8) bool { return false }
+
+	emulator := chip8.NewEmulator(keyPressed, nil)
+	emulator.Quirks = quirks
+	return emulator
+}
+
+func TestEmulator_ShiftRight(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.V[0x1] = 0b00000011
+	emulator.V[0x2] = 0b10000000
+
+	emulator.ShiftRight(0x1, 0x2)
+
+	assert.Equal(t, uint8(0b00000001), emulator.V[0x1])
+	assert.Equal(t, uint8(0x01), emulator.V[0xF])
+}
+
+func TestEmulator_ShiftRight_ShiftVy(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{ShiftVy: true})
+	emulator.V[0x1] = 0b00000011
+	emulator.V[0x2] = 0b10000000
+
+	emulator.ShiftRight(0x1, 0x2)
+
+	assert.Equal(t, uint8(0b01000000), emulator.V[0x1])
+	assert.Equal(t, uint8(0x00), emulator.V[0xF])
+}
+
+func TestEmulator_ShiftLeft_ShiftVy(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{ShiftVy: true})
+	emulator.V[0x1] = 0b00000001
+	emulator.V[0x2] = 0b10000001
+
+	emulator.ShiftLeft(0x1, 0x2)
+
+	assert.Equal(t, uint8(0b00000010), emulator.V[0x1])
+	assert.Equal(t, uint8(0x01), emulator.V[0xF])
+}
+
+func TestEmulator_Or_VFReset(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{VFReset: true})
+	emulator.V[0x1] = 0b0101
+	emulator.V[0x2] = 0b1010
+	emulator.V[0xF] = 0xAA
+
+	emulator.Or(0x1, 0x2)
+
+	assert.Equal(t, uint8(0b1111), emulator.V[0x1])
+	assert.Equal(t, uint8(0x00), emulator.V[0xF])
+}
+
+func TestEmulator_JumpV0(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.V[0x0] = 0x10
+	emulator.V[0x3] = 0x20
+
+	emulator.JumpV0(0x300)
+
+	assert.Equal(t, uint16(0x310), emulator.PC)
+}
+
+func TestEmulator_JumpV0_JumpVx(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{JumpVx: true})
+	emulator.V[0x0] = 0x10
+	emulator.V[0x3] = 0x20
+
+	emulator.JumpV0(0x300)
+
+	assert.Equal(t, uint16(0x320), emulator.PC)
+}
+
+func TestEmulator_StoreRegisters_LoadStoreIncrementI(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{LoadStoreIncrementI: true})
+	emulator.I = 0x300
+	emulator.V[0x0] = 0xAB
+	emulator.V[0x1] = 0xCD
+
+	emulator.StoreRegisters(0x1)
+
+	assert.Equal(t, []uint8{0xAB, 0xCD}, emulator.Memory[0x300:0x302])
+	assert.Equal(t, uint16(0x302), emulator.I)
+}
+
+func TestEmulator_Draw_Wrap(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0xFF
+	emulator.V[0x0] = chip8.Width - 4
+
+	emulator.Draw(0x0, 0x1, 1)
+
+	assert.True(t, emulator.Display.PlanePixel(0, 0, 0))
+}
+
+func TestEmulator_Draw_Clipping(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{Clipping: true})
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0xFF
+	emulator.V[0x0] = chip8.Width - 4
+
+	emulator.Draw(0x0, 0x1, 1)
+
+	assert.False(t, emulator.Display.PlanePixel(0, 0, 0))
+	assert.True(t, emulator.Display.PlanePixel(0, chip8.Width-1, 0))
+}
+
+func TestQuirksByName(t *testing.T) {
+	quirks, ok := chip8.QuirksByName("VIP")
+
+	assert.True(t, ok)
+	assert.Equal(t, chip8.QuirksCOSMACVIP, quirks)
+}
+
+func TestEmulator_SetResolution(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+
+	emulator.SetResolution(true)
+
+	assert.Equal(t, chip8.HiResWidth, emulator.Width())
+	assert.Equal(t, chip8.HiResHeight, emulator.Height())
+	assert.Equal(t, chip8.HiResWidth, emulator.Display.Bounds().Dx())
+	assert.Equal(t, chip8.HiResHeight, emulator.Display.Bounds().Dy())
+}
+
+func TestEmulator_Draw_Large(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	emulator.SetResolution(true)
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0x80
+	emulator.Memory[0x301] = 0x01
+	emulator.Memory[0x31E] = 0xFF
+	emulator.V[0x0] = 100
+	emulator.V[0x1] = 40
+
+	emulator.Draw(0x0, 0x1, 0)
+
+	assert.True(t, emulator.Display.PlanePixel(0, 100, 40))
+	assert.True(t, emulator.Display.PlanePixel(0, 115, 40))
+	assert.True(t, emulator.Display.PlanePixel(0, 100, 55))
+	assert.False(t, emulator.Display.PlanePixel(0, 101, 40))
+	assert.Equal(t, uint8(0x00), emulator.V[0xF])
+}
+
+func TestEmulator_ScrollDown(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0x80
+
+	emulator.Draw(0x0, 0x0, 1)
+	emulator.ScrollDown(3)
+
+	assert.False(t, emulator.Display.PlanePixel(0, 0, 0))
+	assert.True(t, emulator.Display.PlanePixel(0, 0, 3))
+}
+
+func TestEmulator_ScrollLeft(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0x08
+
+	emulator.Draw(0x0, 0x0, 1)
+	emulator.ScrollLeft()
+
+	assert.False(t, emulator.Display.PlanePixel(0, 4, 0))
+	assert.True(t, emulator.Display.PlanePixel(0, 0, 0))
+}
+
+func TestEmulator_SetBigI(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	emulator.V[0x2] = 0x3
+
+	emulator.SetBigI(0x2)
+
+	assert.Equal(t, chip8.BigFontAddress+30, emulator.I)
+	assert.Equal(t, uint8(0x3C), emulator.Memory[emulator.I])
+}
+
+func TestEmulator_StoreFlags(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	emulator.V[0x0] = 0x12
+	emulator.V[0x1] = 0x34
+
+	emulator.StoreFlags(0x1)
+	emulator.V = [16]uint8{}
+	emulator.ReadFlags(0x1)
+
+	assert.Equal(t, uint8(0x12), emulator.V[0x0])
+	assert.Equal(t, uint8(0x34), emulator.V[0x1])
+}
+
+func TestEmulator_Exit(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksSuperChip)
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x00, 0xFD, 0x60, 0x01}})
+
+	emulator.Cycle()
+	emulator.Cycle()
+
+	assert.True(t, emulator.Halted)
+	assert.Equal(t, uint8(0x00), emulator.V[0x0])
+}
+
+func TestEmulator_LoadLongI(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+	emulator.LoadROM(chip8.ROM{Data: []byte{0xF0, 0x00, 0xAB, 0xCD}})
+
+	emulator.Cycle()
+
+	assert.Equal(t, uint16(0xABCD), emulator.I)
+	assert.Equal(t, chip8.ProgramAddress+4, emulator.PC)
+}
+
+func TestEmulator_SkipNext_LongInstruction(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+	emulator.LoadROM(chip8.ROM{Data: []byte{0x30, 0x00, 0xF0, 0x00, 0xAB, 0xCD}})
+
+	emulator.Cycle()
+
+	assert.Equal(t, chip8.ProgramAddress+6, emulator.PC)
+}
+
+func TestEmulator_StoreRange(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+	emulator.I = 0x1000
+	emulator.V[0x3] = 0x33
+	emulator.V[0x4] = 0x44
+	emulator.V[0x5] = 0x55
+
+	emulator.StoreRange(0x5, 0x3)
+
+	assert.Equal(t, []uint8{0x55, 0x44, 0x33}, emulator.Memory[0x1000:0x1003])
+	assert.Equal(t, uint16(0x1000), emulator.I)
+}
+
+func TestEmulator_ReadRange(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+	emulator.I = 0x1000
+	copy(emulator.Memory[0x1000:], []uint8{0x11, 0x22})
+
+	emulator.ReadRange(0x2, 0x3)
+
+	assert.Equal(t, uint8(0x11), emulator.V[0x2])
+	assert.Equal(t, uint8(0x22), emulator.V[0x3])
+}
+
+func TestEmulator_Draw_Planes(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0x80 // plane 1
+	emulator.Memory[0x301] = 0x40 // plane 2
+
+	emulator.SelectPlane(0b11)
+	emulator.Draw(0x0, 0x0, 1)
+
+	assert.True(t, emulator.Display.PlanePixel(0, 0, 0))
+	assert.False(t, emulator.Display.PlanePixel(1, 0, 0))
+	assert.False(t, emulator.Display.PlanePixel(0, 1, 0))
+	assert.True(t, emulator.Display.PlanePixel(1, 1, 0))
+}
+
+func TestEmulator_ClearScreen_Plane(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0x80
+	emulator.Memory[0x301] = 0x80
+	emulator.SelectPlane(0b11)
+	emulator.Draw(0x0, 0x0, 1)
+
+	emulator.SelectPlane(0b10)
+	emulator.ClearScreen()
+
+	assert.True(t, emulator.Display.PlanePixel(0, 0, 0))
+	assert.False(t, emulator.Display.PlanePixel(1, 0, 0))
+}
+
+func TestEmulator_PatternRate(t *testing.T) {
+	emulator := NewTestEmulator(chip8.QuirksXOChip)
+
+	assert.Equal(t, 4000.0, emulator.PatternRate())
+
+	emulator.V[0x0] = 112
+	emulator.SetPitch(0x0)
+
+	assert.Equal(t, 8000.0, emulator.PatternRate())
+}
+
+func TestEmulator_SkipKey_HighValue(t *testing.T) {
+	keys := [chip8.KeyCount]bool{0x0: true}
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.KeyPressed = func(key uint8) bool { return keys[key] }
+	emulator.V[0x1] = 0x20 // key 0
+	emulator.V[0x2] = 0xF5 // key 5
+
+	emulator.PC = chip8.ProgramAddress
+	emulator.SkipKeyPressed(0x1)
+	assert.Equal(t, chip8.ProgramAddress+2, emulator.PC)
+
+	emulator.PC = chip8.ProgramAddress
+	emulator.SkipKeyNotPressed(0x2)
+	assert.Equal(t, chip8.ProgramAddress+2, emulator.PC)
+}
+
+func TestEmulator_ReadKey(t *testing.T) {
+	keys := [chip8.KeyCount]bool{}
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.KeyPressed = func(key uint8) bool { return keys[key] }
+	emulator.LoadROM(chip8.ROM{Data: []byte{0xF3, 0x0A, 0x60, 0x01, 0x12, 0x04}})
+	emulator.DT = 10
+
+	assert.NoError(t, emulator.Update())
+	assert.True(t, emulator.WaitingKey)
+	assert.Equal(t, chip8.ProgramAddress, emulator.PC)
+	assert.Equal(t, uint8(9), emulator.DT)
+
+	keys[0xB] = true
+	assert.NoError(t, emulator.Update())
+	assert.True(t, emulator.WaitingKey)
+	assert.Equal(t, chip8.ProgramAddress, emulator.PC)
+
+	keys[0xB] = false
+	assert.NoError(t, emulator.Update())
+	assert.False(t, emulator.WaitingKey)
+	assert.Equal(t, uint8(0xB), emulator.V[0x3])
+	assert.Equal(t, uint8(0x01), emulator.V[0x0])
+	assert.Equal(t, uint8(7), emulator.DT)
+}
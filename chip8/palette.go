@@ -0,0 +1,85 @@
+package chip8
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Named palettes: background, plane 1, plane 2 and both planes colors.
+var Palettes = map[string]color.Palette{
+	"green": DefaultPalette,
+	"amber": {
+		color.RGBA{0x1A, 0x0F, 0x00, 0xFF},
+		color.RGBA{0xFF, 0xB0, 0x00, 0xFF},
+		color.RGBA{0x99, 0x5C, 0x00, 0xFF},
+		color.RGBA{0xFF, 0xE0, 0x80, 0xFF},
+	},
+	"white": {
+		color.RGBA{0x00, 0x00, 0x00, 0xFF},
+		color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+		color.RGBA{0xAA, 0xAA, 0xAA, 0xFF},
+		color.RGBA{0x55, 0x55, 0x55, 0xFF},
+	},
+	"lcd": {
+		color.RGBA{0x9B, 0xBC, 0x0F, 0xFF},
+		color.RGBA{0x0F, 0x38, 0x0F, 0xFF},
+		color.RGBA{0x8B, 0xAC, 0x0F, 0xFF},
+		color.RGBA{0x30, 0x62, 0x30, 0xFF},
+	},
+	"octo": {
+		color.RGBA{0x99, 0x66, 0x00, 0xFF},
+		color.RGBA{0xFF, 0xCC, 0x00, 0xFF},
+		color.RGBA{0xFF, 0x66, 0x00, 0xFF},
+		color.RGBA{0x66, 0x22, 0x00, 0xFF},
+	},
+}
+
+// Names of all palettes, sorted.
+func PaletteNames() []string {
+	names := make([]string, 0, len(Palettes))
+	for name := range Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse a palette name or a comma separated list of 2 to 4 hex colors
+// (e.g. "#000000,#FFFFFF"). With 2 colors, the second one is used for every plane;
+// with 3, the last one is used for both planes.
+func ParsePalette(spec string) (color.Palette, error) {
+	if palette, ok := Palettes[strings.ToLower(spec)]; ok {
+		return palette, nil
+	}
+
+	hexes := strings.Split(spec, ",")
+	if len(hexes) < 2 || len(hexes) > 4 {
+		return nil, fmt.Errorf("chip8: invalid palette %q", spec)
+	}
+
+	palette := make(color.Palette, 0, 4)
+	for _, hex := range hexes {
+		c, err := ParseColor(hex)
+		if err != nil {
+			return nil, err
+		}
+		palette = append(palette, c)
+	}
+	for len(palette) < 4 {
+		palette = append(palette, palette[len(palette)-1])
+	}
+	return palette, nil
+}
+
+// Parse a #RRGGBB hex color.
+func ParseColor(hex string) (color.RGBA, error) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("chip8: invalid color %q", hex)
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xFF}, nil
+}
@@ -0,0 +1,36 @@
+package chip8_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestParsePalette_Name(t *testing.T) {
+	palette, err := chip8.ParsePalette("Amber")
+
+	assert.NoError(t, err)
+	assert.Equal(t, chip8.Palettes["amber"], palette)
+}
+
+func TestParsePalette_Colors(t *testing.T) {
+	palette, err := chip8.ParsePalette("#102030, ffffff")
+
+	assert.NoError(t, err)
+	assert.Equal(t, color.Palette{
+		color.RGBA{0x10, 0x20, 0x30, 0xFF},
+		color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+		color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+		color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+	}, palette)
+}
+
+func TestParsePalette_Invalid(t *testing.T) {
+	_, err := chip8.ParsePalette("#000000")
+	assert.Error(t, err)
+
+	_, err = chip8.ParsePalette("#000000,#GGGGGG")
+	assert.Error(t, err)
+}
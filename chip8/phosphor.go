@@ -0,0 +1,81 @@
+package chip8
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Phosphor is a display filter that reduces the flicker of sprites erased
+// and redrawn with XOR, emulating the persistence of a CRT phosphor.
+//
+// A pixel turned off stays lit for Hold frames, then fades into the background,
+// keeping Decay of its brightness on each following frame.
+type Phosphor struct {
+	Hold   int         // frames an erased pixel stays fully lit
+	Decay  float64     // brightness kept per frame while fading, 0 turns it off at once
+	Image  *image.RGBA // last filtered frame
+	Fading bool        // some erased pixel is still visible
+
+	lit  []uint8 // last lit color of each pixel
+	ages []int   // frames since each pixel was last lit
+}
+
+func NewPhosphor(hold int, decay float64) *Phosphor {
+	phosphor := new(Phosphor)
+	phosphor.Hold = hold
+	phosphor.Decay = decay
+	return phosphor
+}
+
+// Blend a rendered frame with the previous ones.
+// Pixels with the background color are considered off.
+func (phosphor *Phosphor) Apply(frame *image.RGBA, background color.Color) *image.RGBA {
+	if phosphor.Image == nil || phosphor.Image.Rect != frame.Rect {
+		phosphor.Image = image.NewRGBA(frame.Rect)
+		phosphor.lit = make([]uint8, len(frame.Pix))
+		phosphor.ages = make([]int, len(frame.Pix)/4)
+		for index := range phosphor.ages {
+			phosphor.ages[index] = math.MaxInt32 // never lit
+		}
+	}
+
+	bg := color.RGBAModel.Convert(background).(color.RGBA)
+	off := [4]uint8{bg.R, bg.G, bg.B, bg.A}
+	phosphor.Fading = false
+
+	for index := range phosphor.ages {
+		offset := index * 4
+		pixel := frame.Pix[offset : offset+4]
+		out := phosphor.Image.Pix[offset : offset+4]
+
+		if pixel[0] != off[0] || pixel[1] != off[1] || pixel[2] != off[2] || pixel[3] != off[3] {
+			copy(phosphor.lit[offset:offset+4], pixel)
+			copy(out, pixel)
+			phosphor.ages[index] = 0
+			continue
+		}
+
+		if phosphor.ages[index] < math.MaxInt32 {
+			phosphor.ages[index]++
+		}
+		age := phosphor.ages[index]
+		intensity := 0.0
+		if age <= phosphor.Hold {
+			intensity = 1
+		} else if phosphor.Decay > 0 && age-phosphor.Hold < 64 {
+			intensity = math.Pow(phosphor.Decay, float64(age-phosphor.Hold))
+		}
+		if intensity < 1.0/255 {
+			copy(out, off[:])
+			continue
+		}
+
+		phosphor.Fading = true
+		lit := phosphor.lit[offset : offset+4]
+		for channel := range out {
+			out[channel] = uint8(float64(off[channel]) + (float64(lit[channel])-float64(off[channel]))*intensity)
+		}
+	}
+	return phosphor.Image
+}
@@ -0,0 +1,51 @@
+package chip8_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+var (
+	PhosphorOff = color.RGBA{0x00, 0x00, 0x00, 0xFF}
+	PhosphorOn  = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+func PhosphorFrame(lit bool) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	frame.SetRGBA(0, 0, PhosphorOff)
+	if lit {
+		frame.SetRGBA(0, 0, PhosphorOn)
+	}
+	return frame
+}
+
+func TestPhosphor_Hold(t *testing.T) {
+	phosphor := chip8.NewPhosphor(2, 0)
+
+	assert.Equal(t, PhosphorOn, phosphor.Apply(PhosphorFrame(true), PhosphorOff).RGBAAt(0, 0))
+	assert.Equal(t, PhosphorOn, phosphor.Apply(PhosphorFrame(false), PhosphorOff).RGBAAt(0, 0))
+	assert.Equal(t, PhosphorOn, phosphor.Apply(PhosphorFrame(false), PhosphorOff).RGBAAt(0, 0))
+	assert.True(t, phosphor.Fading)
+	assert.Equal(t, PhosphorOff, phosphor.Apply(PhosphorFrame(false), PhosphorOff).RGBAAt(0, 0))
+	assert.False(t, phosphor.Fading)
+}
+
+func TestPhosphor_Decay(t *testing.T) {
+	phosphor := chip8.NewPhosphor(0, 0.5)
+
+	phosphor.Apply(PhosphorFrame(true), PhosphorOff)
+
+	assert.Equal(t, uint8(0x7F), phosphor.Apply(PhosphorFrame(false), PhosphorOff).RGBAAt(0, 0).R)
+	assert.Equal(t, uint8(0x3F), phosphor.Apply(PhosphorFrame(false), PhosphorOff).RGBAAt(0, 0).R)
+}
+
+func TestPhosphor_NeverLit(t *testing.T) {
+	phosphor := chip8.NewPhosphor(2, 0.5)
+
+	assert.Equal(t, PhosphorOff, phosphor.Apply(PhosphorFrame(false), PhosphorOff).RGBAAt(0, 0))
+	assert.False(t, phosphor.Fading)
+}
@@ -0,0 +1,44 @@
+package chip8
+
+// UnknownOpcodePolicy decides what happens when an opcode isn't decoded.
+type UnknownOpcodePolicy int
+
+const (
+	HaltOnUnknownOpcodes     UnknownOpcodePolicy = iota // stop with an ExecutionError
+	IgnoreUnknownOpcodes                                // skip the opcode
+	CallbackOnUnknownOpcodes                            // call Emulator.OnUnknownOpcode
+)
+
+// Named unknown opcode policies.
+var UnknownOpcodePolicies = map[string]UnknownOpcodePolicy{
+	"halt":     HaltOnUnknownOpcodes,
+	"ignore":   IgnoreUnknownOpcodes,
+	"callback": CallbackOnUnknownOpcodes,
+}
+
+// Handle an opcode that isn't decoded, according to the UnknownOpcodes policy.
+//
+// A callback returning an error halts the program with that cause.
+func (emulator *Emulator) UnknownOpcode(instruction Instruction) error {
+	switch emulator.UnknownOpcodes {
+	case HaltOnUnknownOpcodes:
+		return ErrUnknownOpcode
+	case CallbackOnUnknownOpcodes:
+		if emulator.OnUnknownOpcode != nil {
+			return emulator.OnUnknownOpcode(emulator, instruction)
+		}
+	}
+	return nil
+}
+
+// Call the machine code routine at nnn (0nnn - SYS nnn).
+//
+// The original interpreters ran native CPU code there. It's emulated by the
+// MachineCode function when set, otherwise the opcode is handled as unknown.
+// 0000 is never a valid call: it's most likely a jump into empty memory.
+func (emulator *Emulator) CallMachineCode(instruction Instruction) error {
+	if emulator.MachineCode == nil || instruction.Opcode == 0x0000 {
+		return emulator.UnknownOpcode(instruction)
+	}
+	return emulator.MachineCode(emulator, instruction.NNN)
+}
@@ -0,0 +1,68 @@
+package chip8
+
+import (
+	"sort"
+	"strings"
+)
+
+// Quirks selects between the different interpretations of the ambiguous
+// CHIP-8 instructions. The zero value keeps the emulator's original behaviour.
+type Quirks struct {
+	ShiftVy             bool // 8xy6/8xyE shift Vy and store the result in Vx
+	LoadStoreIncrementI bool // Fx55/Fx65 leave I pointing after the last register
+	VFReset             bool // 8xy1/8xy2/8xy3 reset VF to 0
+	JumpVx              bool // Bxnn jumps to xnn + Vx instead of nnn + V0
+	Clipping            bool // sprites are clipped at the screen edges instead of wrapping
+	DisplayWait         bool // Dxyn waits for the vertical blank before drawing
+}
+
+var (
+	// Original COSMAC VIP interpreter.
+	QuirksCOSMACVIP = Quirks{
+		ShiftVy:             true,
+		LoadStoreIncrementI: true,
+		VFReset:             true,
+		Clipping:            true,
+		DisplayWait:         true,
+	}
+	// CHIP-48 interpreter for the HP-48 calculators.
+	QuirksCHIP48 = Quirks{
+		JumpVx:   true,
+		Clipping: true,
+	}
+	// SUPER-CHIP 1.1 interpreter.
+	QuirksSuperChip = Quirks{
+		JumpVx:   true,
+		Clipping: true,
+	}
+	// XO-CHIP, as implemented by Octo.
+	QuirksXOChip = Quirks{
+		ShiftVy:             true,
+		LoadStoreIncrementI: true,
+	}
+)
+
+// Named quirk presets.
+var QuirkProfiles = map[string]Quirks{
+	"default": {},
+	"vip":     QuirksCOSMACVIP,
+	"chip48":  QuirksCHIP48,
+	"schip":   QuirksSuperChip,
+	"xochip":  QuirksXOChip,
+}
+
+// Find a quirk preset by its name.
+func QuirksByName(name string) (Quirks, bool) {
+	quirks, ok := QuirkProfiles[strings.ToLower(name)]
+	return quirks, ok
+}
+
+// Names of all quirk presets, sorted.
+func QuirkProfileNames() []string {
+	names := make([]string, 0, len(QuirkProfiles))
+	for name := range QuirkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,30 @@
+package chip8
+
+// RandomSource is a seedable pseudo-random number generator (SplitMix64).
+// Its whole state is a single value, so it can be saved and restored.
+type RandomSource struct {
+	State uint64
+}
+
+func NewRandomSource(seed uint64) *RandomSource {
+	source := new(RandomSource)
+	source.Seed(seed)
+	return source
+}
+
+func (source *RandomSource) Seed(seed uint64) {
+	source.State = seed
+}
+
+func (source *RandomSource) Uint64() uint64 {
+	source.State += 0x9E3779B97F4A7C15
+	z := source.State
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Random byte from 0 to 255.
+func (source *RandomSource) Byte() uint8 {
+	return uint8(source.Uint64() >> 56)
+}
@@ -0,0 +1,42 @@
+package chip8_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestRandomSource_Seed(t *testing.T) {
+	a := chip8.NewRandomSource(0xC0FFEE)
+	b := chip8.NewRandomSource(0xC0FFEE)
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, a.Byte(), b.Byte())
+	}
+}
+
+func TestRandomSource_Byte_Range(t *testing.T) {
+	source := chip8.NewRandomSource(1)
+	seen := map[uint8]bool{}
+
+	for i := 0; i < 10000; i++ {
+		seen[source.Byte()] = true
+	}
+
+	assert.Len(t, seen, 256)
+}
+
+func TestEmulator_Random_Reproducible(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.SetSeed(42)
+
+	emulator.Random(0x0, 0xFF)
+	emulator.Random(0x1, 0xFF)
+	first := emulator.V
+	emulator.Reset()
+	emulator.Random(0x0, 0xFF)
+	emulator.Random(0x1, 0xFF)
+
+	assert.Equal(t, first, emulator.V)
+}
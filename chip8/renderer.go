@@ -0,0 +1,80 @@
+package chip8
+
+import (
+	"image"
+	"image/color"
+)
+
+// Colors of the pixels by their planes bitmask: background, plane 1, plane 2, both planes.
+var DefaultPalette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xFF},
+	color.RGBA{0x00, 0xFF, 0x00, 0xFF},
+	color.RGBA{0xFF, 0x00, 0x00, 0xFF},
+	color.RGBA{0xFF, 0xFF, 0x00, 0xFF},
+}
+
+// Renderer draws a FrameBuffer into an image with a palette.
+// The image is reused between frames and resized to follow the frame buffer.
+// Unchanged frames aren't drawn again.
+type Renderer struct {
+	Palette color.Palette // indexed by the pixel planes bitmask
+	Image   *image.RGBA   // last rendered frame
+	Changed bool          // the last Render produced a new frame
+
+	fb         *FrameBuffer  // last rendered frame buffer
+	generation uint64        // last rendered frame buffer generation
+	palette    color.Palette // last used palette
+}
+
+func NewRenderer(palette color.Palette) *Renderer {
+	renderer := new(Renderer)
+	renderer.Palette = palette
+	return renderer
+}
+
+func (renderer *Renderer) Render(fb *FrameBuffer) *image.RGBA {
+	renderer.Changed = renderer.Image == nil || fb != renderer.fb ||
+		fb.Generation != renderer.generation || !samePalette(renderer.Palette, renderer.palette)
+	if !renderer.Changed {
+		return renderer.Image
+	}
+	renderer.fb, renderer.generation = fb, fb.Generation
+	renderer.palette = append(renderer.palette[:0], renderer.Palette...)
+
+	if renderer.Image == nil || renderer.Image.Rect != fb.Bounds() {
+		renderer.Image = image.NewRGBA(fb.Bounds())
+	}
+
+	colors := make([]color.RGBA, len(renderer.Palette))
+	for index, c := range renderer.Palette {
+		colors[index] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+
+	for y := 0; y < fb.Height; y++ {
+		for x := 0; x < fb.Width; x++ {
+			pixel := int(fb.Pixel(x, y))
+			if pixel >= len(colors) {
+				pixel = len(colors) - 1
+			}
+			c := colors[pixel]
+			offset := renderer.Image.PixOffset(x, y)
+			renderer.Image.Pix[offset+0] = c.R
+			renderer.Image.Pix[offset+1] = c.G
+			renderer.Image.Pix[offset+2] = c.B
+			renderer.Image.Pix[offset+3] = c.A
+		}
+	}
+	return renderer.Image
+}
+
+func samePalette(a color.Palette, b color.Palette) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
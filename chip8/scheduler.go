@@ -0,0 +1,76 @@
+package chip8
+
+import "time"
+
+// scheduler clocks unit: an event is due every second
+const second = int64(time.Second)
+
+const (
+	DefaultInstructionsPerSecond = CyclesPerFrame * FPS
+	DefaultTimerRate             = FPS                    // DT and ST decrement at 60Hz
+	MaxElapsed                   = 250 * time.Millisecond // longer host pauses are not caught up
+)
+
+// Run the instructions and timer ticks due in the elapsed host time.
+//
+// Instructions run at InstructionsPerSecond and the timers tick at TimerRate,
+// interleaved in the order they are due. The fraction of an instruction or tick
+// left over is carried to the next call, so the rates don't drift regardless
+// of how often the frontend calls it.
+func (emulator *Emulator) Advance(elapsed time.Duration) error {
+	if emulator.Error != nil {
+		emulator.setBuzzer(false)
+		return emulator.Error
+	}
+	if elapsed > MaxElapsed {
+		elapsed = MaxElapsed
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	// clocks are in nanoseconds times the rate; an event is due every second
+	ips, rate := int64(emulator.InstructionsPerSecond), int64(emulator.TimerRate)
+	emulator.cycleClock += int64(elapsed) * ips
+	emulator.timerClock += int64(elapsed) * rate
+	emulator.sampleClock += int64(elapsed) * int64(emulator.Sound.SampleRate)
+
+	for emulator.cycleClock >= second || emulator.timerClock >= second {
+		cycleOverdue := (emulator.cycleClock - second) * rate
+		timerOverdue := (emulator.timerClock - second) * ips
+
+		if emulator.timerClock >= second && (emulator.cycleClock < second || timerOverdue >= cycleOverdue) {
+			emulator.generateSamples(lag(emulator.timerClock, rate))
+			emulator.timerClock -= second
+			emulator.UpdateTimers()
+			emulator.waitVBlank = false
+			continue
+		}
+
+		emulator.generateSamples(lag(emulator.cycleClock, ips))
+		emulator.cycleClock -= second
+		if emulator.waitVBlank {
+			continue // display wait: idle until the next tick
+		}
+		if err := emulator.Cycle(); err != nil {
+			emulator.setBuzzer(false)
+			return err
+		}
+	}
+	emulator.generateSamples(0)
+	return nil
+}
+
+// Nanoseconds since the event of a clock became due.
+func lag(clock int64, rate int64) int64 {
+	if rate <= 0 {
+		return 0
+	}
+	return (clock - second) / rate
+}
+
+// Restart the scheduler with an instruction and a timer tick due right away.
+func (emulator *Emulator) resetClocks() {
+	emulator.cycleClock = int64(time.Second)
+	emulator.timerClock = int64(time.Second)
+}
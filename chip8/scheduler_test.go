@@ -0,0 +1,79 @@
+package chip8_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// 7001 - ADD V0, 1 followed by 1200 - JP 200
+var CounterROM = chip8.ROM{Data: []byte{0x70, 0x01, 0x12, 0x00}}
+
+// 1200 - JP 200
+var IdleROM = chip8.ROM{Data: []byte{0x12, 0x00}}
+
+// Advance the emulator by one second, in steps.
+func AdvanceSecond(t *testing.T, emulator *chip8.Emulator, steps int) {
+	for i := 0; i < steps; i++ {
+		assert.NoError(t, emulator.Advance(time.Second/time.Duration(steps)))
+	}
+}
+
+func TestEmulator_Advance(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(CounterROM)
+	emulator.InstructionsPerSecond = 200
+	emulator.DT = 0xFF
+
+	AdvanceSecond(t, emulator, 10)
+
+	// one instruction and one tick are due right after reset
+	assert.Equal(t, uint8(101), emulator.V[0x0])
+	assert.Equal(t, uint8(0xFF-61), emulator.DT)
+}
+
+func TestEmulator_Advance_TimerRate(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(IdleROM)
+	emulator.TimerRate = 50
+	emulator.DT = 0xFF
+
+	AdvanceSecond(t, emulator, 10)
+
+	assert.Equal(t, uint8(0xFF-51), emulator.DT)
+}
+
+func TestEmulator_Advance_NoDrift(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(CounterROM)
+	emulator.InstructionsPerSecond = 240
+	emulator.DT = 0xFF
+
+	AdvanceSecond(t, emulator, 1000)
+
+	assert.Equal(t, uint8(121), emulator.V[0x0])
+	assert.Equal(t, uint8(0xFF-61), emulator.DT)
+}
+
+func TestEmulator_Advance_MaxElapsed(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(IdleROM)
+	emulator.DT = 0xFF
+
+	assert.NoError(t, emulator.Advance(time.Hour))
+
+	assert.Equal(t, uint8(0xFF-16), emulator.DT)
+}
+
+func TestEmulator_Advance_DisplayWait(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{DisplayWait: true})
+	// D001 - DRW V0, V0, 1 followed by 1200 - JP 200
+	emulator.LoadROM(chip8.ROM{Data: []byte{0xD0, 0x01, 0x12, 0x00}})
+	emulator.InstructionsPerSecond = 6000
+
+	assert.NoError(t, emulator.Advance(time.Second/chip8.FPS))
+
+	assert.Equal(t, chip8.ProgramAddress+2, emulator.PC)
+}
@@ -0,0 +1,36 @@
+package chip8
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Render the display with a palette, enlarged by an integer scale.
+// Scales below 1 keep the native size.
+func (emulator *Emulator) ScreenshotImage(palette color.Palette, scale int) *image.RGBA {
+	frame := NewRenderer(palette).Render(emulator.Display)
+	if scale <= 1 {
+		return frame
+	}
+
+	size := frame.Rect.Size()
+	scaled := image.NewRGBA(image.Rect(0, 0, size.X*scale, size.Y*scale))
+	for y := 0; y < scaled.Rect.Dy(); y++ {
+		row := scaled.Pix[y*scaled.Stride : y*scaled.Stride+scaled.Rect.Dx()*4]
+		source := frame.Pix[(y/scale)*frame.Stride:]
+		for x := 0; x < len(row); x += 4 {
+			copy(row[x:x+4], source[(x/4/scale)*4:])
+		}
+	}
+	return scaled
+}
+
+// Write the display as a PNG image.
+//
+// The image is drawn with the palette, enlarged by an integer scale
+// (1 or less for the native 64x32 or 128x64 size).
+func (emulator *Emulator) Screenshot(w io.Writer, palette color.Palette, scale int) error {
+	return png.Encode(w, emulator.ScreenshotImage(palette, scale))
+}
@@ -0,0 +1,36 @@
+package chip8_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestEmulator_Screenshot(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.Display.Toggle(0, 1, 0)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, emulator.Screenshot(&buffer, chip8.Palettes["white"], 3))
+
+	img, err := png.Decode(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, chip8.Width*3, chip8.Height*3), img.Bounds())
+	assert.Equal(t, chip8.Palettes["white"][0], img.At(2, 2))
+	assert.Equal(t, chip8.Palettes["white"][1], img.At(3, 0))
+	assert.Equal(t, chip8.Palettes["white"][1], img.At(5, 2))
+	assert.Equal(t, chip8.Palettes["white"][0], img.At(6, 2))
+}
+
+func TestEmulator_ScreenshotImage_Native(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.SetResolution(true)
+
+	img := emulator.ScreenshotImage(chip8.DefaultPalette, 0)
+
+	assert.Equal(t, image.Rect(0, 0, chip8.HiResWidth, chip8.HiResHeight), img.Bounds())
+}
@@ -16,21 +16,22 @@ func (stack *Stack) Clear() {
 	stack.Values = make([]uint16, 0, StackSize)
 }
 
-func (stack *Stack) Push(value uint16) {
+func (stack *Stack) Push(value uint16) error {
 	if len(stack.Values) == cap(stack.Values) {
-		panic("chip8: stack overflow")
+		return ErrStackOverflow
 	}
 	stack.Values = append(stack.Values, value)
+	return nil
 }
 
-func (stack *Stack) Pop() uint16 {
+func (stack *Stack) Pop() (uint16, error) {
 	if len(stack.Values) == 0 {
-		panic("chip8: nothing to pop from stack")
+		return 0x00, ErrStackUnderflow
 	}
 	n := len(stack.Values) - 1
 	defer func() {
 		stack.Values[n] = 0x00
 		stack.Values = stack.Values[:n]
 	}()
-	return stack.Values[n]
+	return stack.Values[n], nil
 }
@@ -10,9 +10,9 @@ import (
 func TestStack_Push(t *testing.T) {
 	stack := chip8.NewStack()
 
-	stack.Push(0xABCD)
-	stack.Push(0xCDEF)
-	stack.Push(0x9999)
+	assert.NoError(t, stack.Push(0xABCD))
+	assert.NoError(t, stack.Push(0xCDEF))
+	assert.NoError(t, stack.Push(0x9999))
 
 	assert.Equal(t, []uint16{0xABCD, 0xCDEF, 0x9999}, stack.Values)
 }
@@ -20,13 +20,12 @@ func TestStack_Push(t *testing.T) {
 func TestStack_Push_Overflow(t *testing.T) {
 	stack := chip8.NewStack()
 
-	defer func() {
-		assert.Equal(t, "chip8: stack overflow", recover())
-	}()
-
-	for {
-		stack.Push(0xCAFE)
+	for i := 0; i < chip8.StackSize; i++ {
+		assert.NoError(t, stack.Push(0xCAFE))
 	}
+
+	assert.Equal(t, chip8.ErrStackOverflow, stack.Push(0xCAFE))
+	assert.Len(t, stack.Values, chip8.StackSize)
 }
 
 func TestEmulator_StackPop(t *testing.T) {
@@ -34,16 +33,16 @@ func TestEmulator_StackPop(t *testing.T) {
 
 	stack.Values = append(stack.Values, 0xCAFE)
 
-	assert.Equal(t, uint16(0xCAFE), stack.Pop())
+	value, err := stack.Pop()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0xCAFE), value)
 	assert.Equal(t, []uint16{}, stack.Values)
 }
 
 func TestEmulator_StackPop_Empty(t *testing.T) {
 	stack := chip8.NewStack()
 
-	defer func() {
-		assert.Equal(t, "chip8: nothing to pop from stack", recover())
-	}()
+	_, err := stack.Pop()
 
-	stack.Pop()
+	assert.Equal(t, chip8.ErrStackUnderflow, err)
 }
@@ -0,0 +1,186 @@
+package chip8
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	StateMagic   = "CH8S" // save state file signature
+	StateVersion = 2      // save state format version
+)
+
+var (
+	ErrInvalidState  = errors.New("chip8: invalid save state")
+	ErrStateVersion  = errors.New("chip8: unsupported save state version")
+	ErrStateChecksum = errors.New("chip8: save state checksum mismatch")
+	ErrStateROM      = errors.New("chip8: save state belongs to a different ROM")
+)
+
+// Save state header, followed by the snapshot, the display bitplanes
+// and the CRC-32 of everything before it.
+type stateHeader struct {
+	Magic   [4]byte
+	Version uint16
+	ROM     [sha256.Size]byte // ROM identity
+}
+
+// Emulator state, excluding the display.
+type snapshot struct {
+	V             [16]uint8
+	I             uint16
+	PC            uint16
+	DT            uint8
+	ST            uint8
+	StackSize     uint8
+	Stack         [StackSize]uint16
+	Memory        [MemorySize]uint8
+	RPL           [16]uint8
+	HiRes         bool
+	Plane         uint8
+	Pattern       [16]uint8
+	Pitch         uint8
+	PatternLoaded bool
+	Halted        bool
+	WaitingKey    bool
+	PressedKey    uint8
+	Seed          uint64
+	RandomState   uint64
+	WaitVBlank    bool
+	CycleClock    int64
+	TimerClock    int64
+}
+
+// ROM identity, used to match save states.
+func (rom ROM) Hash() [sha256.Size]byte {
+	return sha256.Sum256(rom.Data)
+}
+
+// Write the full emulator state.
+//
+// A program halted by an execution error is saved as running, since the error
+// would be raised again by the same instruction after loading.
+func (emulator *Emulator) SaveState(w io.Writer) error {
+	header := stateHeader{Version: StateVersion, ROM: emulator.ROM.Hash()}
+	copy(header.Magic[:], StateMagic)
+
+	state := snapshot{
+		V:             emulator.V,
+		I:             emulator.I,
+		PC:            emulator.PC,
+		DT:            emulator.DT,
+		ST:            emulator.ST,
+		StackSize:     uint8(len(emulator.Stack.Values)),
+		Memory:        emulator.Memory,
+		RPL:           emulator.RPL,
+		HiRes:         emulator.HiRes,
+		Plane:         emulator.Plane,
+		Pattern:       emulator.Pattern,
+		Pitch:         emulator.Pitch,
+		PatternLoaded: emulator.PatternLoaded,
+		Halted:        emulator.Halted && emulator.Error == nil,
+		WaitingKey:    emulator.WaitingKey,
+		PressedKey:    emulator.PressedKey,
+		Seed:          emulator.Seed,
+		RandomState:   emulator.RNG.State,
+		WaitVBlank:    emulator.waitVBlank,
+		CycleClock:    emulator.cycleClock,
+		TimerClock:    emulator.timerClock,
+	}
+	copy(state.Stack[:], emulator.Stack.Values)
+
+	buffer := new(bytes.Buffer)
+	binary.Write(buffer, binary.BigEndian, header)
+	binary.Write(buffer, binary.BigEndian, state)
+	for _, plane := range emulator.Display.Planes {
+		buffer.Write(plane)
+	}
+	binary.Write(buffer, binary.BigEndian, crc32.ChecksumIEEE(buffer.Bytes()))
+
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// Restore the emulator state written by SaveState.
+//
+// The state must belong to the loaded ROM. The emulator is left untouched on error.
+func (emulator *Emulator) LoadState(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var header stateHeader
+	var state snapshot
+	headerSize, stateSize := binary.Size(header), binary.Size(state)
+	if len(data) < headerSize+stateSize+4 {
+		return ErrInvalidState
+	}
+
+	checksum := binary.BigEndian.Uint32(data[len(data)-4:])
+	data = data[:len(data)-4]
+	binary.Read(bytes.NewReader(data), binary.BigEndian, &header)
+
+	switch {
+	case string(header.Magic[:]) != StateMagic:
+		return ErrInvalidState
+	case header.Version != StateVersion:
+		return ErrStateVersion
+	case crc32.ChecksumIEEE(data) != checksum:
+		return ErrStateChecksum
+	case header.ROM != emulator.ROM.Hash():
+		return ErrStateROM
+	}
+
+	binary.Read(bytes.NewReader(data[headerSize:]), binary.BigEndian, &state)
+	planes := data[headerSize+stateSize:]
+	if int(state.StackSize) > StackSize || len(planes) != displaySize(state.HiRes) {
+		return ErrInvalidState
+	}
+	if state.PressedKey >= KeyCount && state.PressedKey != NoKey || state.Plane > 0b11 {
+		return ErrInvalidState
+	}
+
+	emulator.V = state.V
+	emulator.I = state.I
+	emulator.PC = state.PC
+	emulator.DT = state.DT
+	emulator.ST = state.ST
+	emulator.Stack.Clear()
+	emulator.Stack.Values = append(emulator.Stack.Values, state.Stack[:state.StackSize]...)
+	emulator.Memory = state.Memory
+	emulator.RPL = state.RPL
+	emulator.SetResolution(state.HiRes)
+	for _, plane := range emulator.Display.Planes {
+		planes = planes[copy(plane, planes):]
+	}
+	emulator.Display.Generation++
+	emulator.Plane = state.Plane
+	emulator.Pattern = state.Pattern
+	emulator.Pitch = state.Pitch
+	emulator.Halted = state.Halted
+	emulator.Error = nil
+	emulator.WaitingKey = state.WaitingKey
+	emulator.PressedKey = state.PressedKey
+	emulator.Seed = state.Seed
+	emulator.RNG.State = state.RandomState
+	emulator.waitVBlank = state.WaitVBlank
+	emulator.cycleClock = state.CycleClock
+	emulator.timerClock = state.TimerClock
+	emulator.PatternLoaded = state.PatternLoaded
+	emulator.setBuzzer(emulator.ST != 0)
+	return nil
+}
+
+// Size in bytes of the display bitplanes for a resolution.
+func displaySize(hires bool) int {
+	if hires {
+		return HiResWidth / 8 * HiResHeight * PlaneCount
+	}
+	return Width / 8 * Height * PlaneCount
+}
@@ -0,0 +1,91 @@
+package chip8_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestEmulator_SaveState(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(CounterROM)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, emulator.Update())
+	}
+	emulator.Stack.Push(0x0ABC)
+	emulator.V[0x3] = 0x33
+	emulator.I = 0x300
+	emulator.Memory[0x300] = 0xF0
+	emulator.Draw(0x0, 0x0, 1)
+
+	state := new(bytes.Buffer)
+	assert.NoError(t, emulator.SaveState(state))
+
+	restored := NewTestEmulator(chip8.Quirks{})
+	restored.LoadROM(CounterROM)
+	assert.NoError(t, restored.LoadState(bytes.NewReader(state.Bytes())))
+
+	assert.Equal(t, emulator.V, restored.V)
+	assert.Equal(t, emulator.I, restored.I)
+	assert.Equal(t, emulator.PC, restored.PC)
+	assert.Equal(t, emulator.DT, restored.DT)
+	assert.Equal(t, emulator.Stack.Values, restored.Stack.Values)
+	assert.Equal(t, emulator.Memory, restored.Memory)
+	assert.Equal(t, emulator.Display.Planes, restored.Display.Planes)
+	assert.Equal(t, emulator.RNG.State, restored.RNG.State)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, emulator.Update())
+		assert.NoError(t, restored.Update())
+	}
+	assert.Equal(t, emulator.V, restored.V)
+}
+
+func TestEmulator_LoadState_DifferentROM(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(CounterROM)
+	state := new(bytes.Buffer)
+	assert.NoError(t, emulator.SaveState(state))
+
+	other := NewTestEmulator(chip8.Quirks{})
+	other.LoadROM(chip8.ROM{Data: []byte{0x12, 0x00}})
+
+	assert.Equal(t, chip8.ErrStateROM, other.LoadState(state))
+}
+
+func TestEmulator_LoadState_Checksum(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(CounterROM)
+	state := new(bytes.Buffer)
+	assert.NoError(t, emulator.SaveState(state))
+
+	data := state.Bytes()
+	data[100] ^= 0xFF
+
+	assert.Equal(t, chip8.ErrStateChecksum, emulator.LoadState(bytes.NewReader(data)))
+}
+
+func TestEmulator_LoadState_Invalid(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+
+	assert.Equal(t, chip8.ErrInvalidState, emulator.LoadState(bytes.NewReader([]byte("CH8S"))))
+}
+
+func TestEmulator_LoadState_OutOfRange(t *testing.T) {
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(CounterROM)
+
+	for _, corrupt := range []func(){
+		func() { emulator.PressedKey = 0x20 },
+		func() { emulator.Plane = 0x04 },
+	} {
+		emulator.Reset()
+		corrupt()
+		state := new(bytes.Buffer)
+		assert.NoError(t, emulator.SaveState(state))
+
+		assert.Equal(t, chip8.ErrInvalidState, emulator.LoadState(state))
+	}
+}
@@ -0,0 +1,99 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const wavHeaderSize = 44
+
+// Encode 16-bit PCM samples as a WAV file.
+func EncodeWAV(samples []int16, sampleRate int, channels int) []byte {
+	buffer := new(bytes.Buffer)
+	buffer.Write(wavHeader(len(samples)*2, sampleRate, channels))
+	binary.Write(buffer, binary.LittleEndian, samples)
+	return buffer.Bytes()
+}
+
+func wavHeader(size int, sampleRate int, channels int) []byte {
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("RIFF")
+	binary.Write(buffer, binary.LittleEndian, uint32(36+size))
+	buffer.WriteString("WAVE")
+	buffer.WriteString("fmt ")
+	binary.Write(buffer, binary.LittleEndian, uint32(16))                    // chunk size
+	binary.Write(buffer, binary.LittleEndian, uint16(1))                     // PCM
+	binary.Write(buffer, binary.LittleEndian, uint16(channels))              // channels
+	binary.Write(buffer, binary.LittleEndian, uint32(sampleRate))            // sample rate
+	binary.Write(buffer, binary.LittleEndian, uint32(sampleRate*channels*2)) // byte rate
+	binary.Write(buffer, binary.LittleEndian, uint16(channels*2))            // block align
+	binary.Write(buffer, binary.LittleEndian, uint16(16))                    // bits per sample
+	buffer.WriteString("data")
+	binary.Write(buffer, binary.LittleEndian, uint32(size))
+	return buffer.Bytes()
+}
+
+// WAVRecorder writes the emulator audio to a 16-bit mono PCM WAV file.
+//
+// Capture is called once per frame, at FPS, with the samples generated in
+// the frame. Each frame writes exactly its share of SampleRate samples:
+// extra samples are carried to the next frame and missing ones are silent,
+// so the sound track lines up with the frames captured by a GIFRecorder.
+// The sizes in the WAV header are written by Close.
+type WAVRecorder struct {
+	SampleRate int // samples per second
+	Frames     int // frames captured
+	Samples    int // samples written
+
+	w       io.WriteSeeker
+	pending []int16 // samples generated ahead of the frames
+	buffer  []int16 // samples of the current frame
+}
+
+// Start recording to w, writing the WAV header.
+func NewWAVRecorder(w io.WriteSeeker, sampleRate int) (*WAVRecorder, error) {
+	if _, err := w.Write(wavHeader(0, sampleRate, 1)); err != nil {
+		return nil, err
+	}
+	recorder := new(WAVRecorder)
+	recorder.SampleRate = sampleRate
+	recorder.w = w
+	return recorder, nil
+}
+
+// Add a frame of audio to the recording.
+func (recorder *WAVRecorder) Capture(samples []int16) error {
+	recorder.pending = append(recorder.pending, samples...)
+	recorder.Frames++
+
+	due := recorder.Frames*recorder.SampleRate/FPS - recorder.Samples
+	taken := due
+	if taken > len(recorder.pending) {
+		taken = len(recorder.pending)
+	}
+	recorder.buffer = append(recorder.buffer[:0], recorder.pending[:taken]...)
+	for len(recorder.buffer) < due {
+		recorder.buffer = append(recorder.buffer, 0)
+	}
+	recorder.pending = recorder.pending[:copy(recorder.pending, recorder.pending[taken:])]
+	if overflow := len(recorder.pending) - MaxBufferedSamples; overflow > 0 {
+		recorder.pending = recorder.pending[:copy(recorder.pending, recorder.pending[overflow:])]
+	}
+
+	recorder.Samples += len(recorder.buffer)
+	return binary.Write(recorder.w, binary.LittleEndian, recorder.buffer)
+}
+
+// Finish the recording, writing the sizes in the WAV header.
+// The writer is left at the end of the file.
+func (recorder *WAVRecorder) Close() error {
+	if _, err := recorder.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := recorder.w.Write(wavHeader(recorder.Samples*2, recorder.SampleRate, 1)); err != nil {
+		return err
+	}
+	_, err := recorder.w.Seek(0, io.SeekEnd)
+	return err
+}
@@ -0,0 +1,65 @@
+package chip8_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+func TestEncodeWAV(t *testing.T) {
+	wav := chip8.EncodeWAV([]int16{1, -1}, chip8.SampleRate, 1)
+
+	assert.Len(t, wav, 44+4)
+	assert.Equal(t, "RIFF", string(wav[0:4]))
+	assert.Equal(t, uint32(36+4), binary.LittleEndian.Uint32(wav[4:8]))
+	assert.Equal(t, uint32(chip8.SampleRate), binary.LittleEndian.Uint32(wav[24:28]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(wav[40:44]))
+}
+
+func TestWAVRecorder_Headless(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sound.wav")
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	emulator := NewTestEmulator(chip8.Quirks{})
+	emulator.LoadROM(BuzzerROM)
+	recorder, err := chip8.NewWAVRecorder(file, chip8.SampleRate)
+	assert.NoError(t, err)
+
+	samples := make([]int16, chip8.MaxBufferedSamples)
+	for frame := 0; frame < chip8.FPS; frame++ {
+		assert.NoError(t, emulator.Update())
+		assert.NoError(t, recorder.Capture(samples[:emulator.ReadSamples(samples)]))
+	}
+	assert.NoError(t, recorder.Close())
+
+	wav, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, chip8.SampleRate, recorder.Samples)
+	assert.Len(t, wav, 44+chip8.SampleRate*2)
+	assert.Equal(t, uint32(36+chip8.SampleRate*2), binary.LittleEndian.Uint32(wav[4:8]))
+	assert.Equal(t, uint32(chip8.SampleRate*2), binary.LittleEndian.Uint32(wav[40:44]))
+	assert.NotZero(t, binary.LittleEndian.Uint16(wav[44+200:]))
+}
+
+func TestWAVRecorder_FramePacing(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "sound.wav"))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	recorder, err := chip8.NewWAVRecorder(file, chip8.SampleRate)
+	assert.NoError(t, err)
+
+	assert.NoError(t, recorder.Capture(make([]int16, 1000))) // early samples are carried
+	assert.Equal(t, 735, recorder.Samples)
+	assert.NoError(t, recorder.Capture(nil))
+	assert.Equal(t, 1470, recorder.Samples) // 265 carried + 470 of silence
+	assert.NoError(t, recorder.Capture(make([]int16, 700)))
+	assert.Equal(t, 2205, recorder.Samples)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tangzero/chip8-emulator/input"
+)
+
+var Deadzone = flag.Float64("deadzone", input.DefaultDeadzone, "gamepad stick distance from the center ignored, from 0 to 1")
+
+// Gamepad buttons mapped to the CHIP-8 keys.
+var Gamepads = input.NewGamepads(EbitenGamepads{}, input.DefaultGamepad)
+
+// Standard ebiten gamepad buttons of the input gamepad buttons.
+var StandardGamepadButtons = [input.GamepadButtonCount]ebiten.StandardGamepadButton{
+	input.ButtonA:          ebiten.StandardGamepadButtonRightBottom,
+	input.ButtonB:          ebiten.StandardGamepadButtonRightRight,
+	input.ButtonX:          ebiten.StandardGamepadButtonRightLeft,
+	input.ButtonY:          ebiten.StandardGamepadButtonRightTop,
+	input.ButtonL1:         ebiten.StandardGamepadButtonFrontTopLeft,
+	input.ButtonR1:         ebiten.StandardGamepadButtonFrontTopRight,
+	input.ButtonL2:         ebiten.StandardGamepadButtonFrontBottomLeft,
+	input.ButtonR2:         ebiten.StandardGamepadButtonFrontBottomRight,
+	input.ButtonSelect:     ebiten.StandardGamepadButtonCenterLeft,
+	input.ButtonStart:      ebiten.StandardGamepadButtonCenterRight,
+	input.ButtonLeftStick:  ebiten.StandardGamepadButtonLeftStick,
+	input.ButtonRightStick: ebiten.StandardGamepadButtonRightStick,
+	input.ButtonUp:         ebiten.StandardGamepadButtonLeftTop,
+	input.ButtonDown:       ebiten.StandardGamepadButtonLeftBottom,
+	input.ButtonLeft:       ebiten.StandardGamepadButtonLeftLeft,
+	input.ButtonRight:      ebiten.StandardGamepadButtonLeftRight,
+}
+
+// EbitenGamepads reads the gamepads with the standard layout.
+// Gamepads without a known layout are ignored.
+type EbitenGamepads struct{}
+
+func (EbitenGamepads) GamepadIDs() []int {
+	var ids []int
+	for _, id := range ebiten.GamepadIDs() {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			ids = append(ids, int(id))
+		}
+	}
+	return ids
+}
+
+func (EbitenGamepads) ButtonPressed(id int, button input.GamepadButton) bool {
+	return ebiten.IsStandardGamepadButtonPressed(ebiten.GamepadID(id), StandardGamepadButtons[button])
+}
+
+func (EbitenGamepads) LeftStick(id int) (float64, float64) {
+	x := ebiten.StandardGamepadAxisValue(ebiten.GamepadID(id), ebiten.StandardGamepadAxisLeftStickHorizontal)
+	y := ebiten.StandardGamepadAxisValue(ebiten.GamepadID(id), ebiten.StandardGamepadAxisLeftStickVertical)
+	return x, y
+}
+
+// Read the gamepads, before the emulator runs the frame.
+func UpdateGamepads() {
+	connected, disconnected := Gamepads.Update()
+	for _, id := range connected {
+		log.Printf("gamepad connected: %s", ebiten.GamepadName(ebiten.GamepadID(id)))
+	}
+	for _, id := range disconnected {
+		log.Printf("gamepad %d disconnected", id)
+	}
+}
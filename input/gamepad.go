@@ -0,0 +1,180 @@
+package input
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// Buttons of a gamepad with the standard layout: face buttons by their
+// Xbox names, shoulders and triggers, and the D-pad.
+type GamepadButton int
+
+const (
+	ButtonA GamepadButton = iota // bottom face button
+	ButtonB                      // right face button
+	ButtonX                      // left face button
+	ButtonY                      // top face button
+	ButtonL1
+	ButtonR1
+	ButtonL2
+	ButtonR2
+	ButtonSelect
+	ButtonStart
+	ButtonLeftStick
+	ButtonRightStick
+	ButtonUp
+	ButtonDown
+	ButtonLeft
+	ButtonRight
+	GamepadButtonCount
+)
+
+var GamepadButtons = map[string]GamepadButton{
+	"a":          ButtonA,
+	"b":          ButtonB,
+	"x":          ButtonX,
+	"y":          ButtonY,
+	"l1":         ButtonL1,
+	"r1":         ButtonR1,
+	"l2":         ButtonL2,
+	"r2":         ButtonR2,
+	"select":     ButtonSelect,
+	"start":      ButtonStart,
+	"leftstick":  ButtonLeftStick,
+	"rightstick": ButtonRightStick,
+	"up":         ButtonUp,
+	"down":       ButtonDown,
+	"left":       ButtonLeft,
+	"right":      ButtonRight,
+}
+
+// Find a gamepad button by its name.
+func GamepadButtonByName(name string) (GamepadButton, bool) {
+	button, ok := GamepadButtons[strings.ToLower(name)]
+	return button, ok
+}
+
+// Names of all gamepad buttons, sorted.
+func GamepadButtonNames() []string {
+	names := make([]string, 0, len(GamepadButtons))
+	for name := range GamepadButtons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default gamepad buttons: the D-pad on 2/4/6/8, the arrows of most games,
+// and 5 on A, the usual fire or select key.
+var DefaultGamepad = Keymap{
+	0x0: {"Select"},
+	0x1: {"L1"},
+	0x2: {"Up"},
+	0x3: {"R1"},
+	0x4: {"Left"},
+	0x5: {"A"},
+	0x6: {"Right"},
+	0x7: {"L2"},
+	0x8: {"Down"},
+	0x9: {"R2"},
+	0xA: {"B"},
+	0xB: {"X"},
+	0xC: {"Y"},
+	0xD: {"LeftStick"},
+	0xE: {"RightStick"},
+	0xF: {"Start"},
+}
+
+const DefaultDeadzone = 0.3
+
+// GamepadSource reads the connected gamepads, implemented by the frontends.
+type GamepadSource interface {
+	GamepadIDs() []int
+	ButtonPressed(id int, button GamepadButton) bool
+	LeftStick(id int) (x float64, y float64) // from -1 to 1, y pointing down
+}
+
+// Gamepads maps the buttons of every connected gamepad to the CHIP-8 keys.
+// The left stick works as the D-pad.
+type Gamepads struct {
+	Source    GamepadSource
+	Keymap    Keymap  // gamepad buttons by CHIP-8 key
+	Deadzone  float64 // stick distance from the center ignored, from 0 to 1
+	Connected []int   // gamepads found by the last Update
+
+	pressed [chip8.KeyCount]bool
+}
+
+func NewGamepads(source GamepadSource, keymap Keymap) *Gamepads {
+	gamepads := new(Gamepads)
+	gamepads.Source = source
+	gamepads.Keymap = keymap
+	gamepads.Deadzone = DefaultDeadzone
+	return gamepads
+}
+
+// Read the state of the gamepads, once per frame.
+//
+// Gamepads may be plugged and unplugged at any time: the ones connected and
+// disconnected since the previous update are returned.
+func (gamepads *Gamepads) Update() (connected []int, disconnected []int) {
+	ids := gamepads.Source.GamepadIDs()
+	connected, disconnected = difference(ids, gamepads.Connected), difference(gamepads.Connected, ids)
+	gamepads.Connected = append(gamepads.Connected[:0], ids...)
+
+	var buttons [GamepadButtonCount]bool
+	for _, id := range ids {
+		for button := GamepadButton(0); button < GamepadButtonCount; button++ {
+			buttons[button] = buttons[button] || gamepads.Source.ButtonPressed(id, button)
+		}
+		up, down, left, right := gamepads.stick(gamepads.Source.LeftStick(id))
+		buttons[ButtonUp] = buttons[ButtonUp] || up
+		buttons[ButtonDown] = buttons[ButtonDown] || down
+		buttons[ButtonLeft] = buttons[ButtonLeft] || left
+		buttons[ButtonRight] = buttons[ButtonRight] || right
+	}
+
+	for key, names := range gamepads.Keymap {
+		gamepads.pressed[key] = false
+		for _, name := range names {
+			if button, ok := GamepadButtonByName(name); ok && buttons[button] {
+				gamepads.pressed[key] = true
+			}
+		}
+	}
+	return connected, disconnected
+}
+
+// Whether a CHIP-8 key was pressed on any gamepad at the last Update.
+func (gamepads *Gamepads) Pressed(key uint8) bool {
+	return gamepads.pressed[key]
+}
+
+// Convert a stick position to D-pad directions. Positions within 22.5°
+// of a diagonal press both directions.
+func (gamepads *Gamepads) stick(x float64, y float64) (up bool, down bool, left bool, right bool) {
+	distance := math.Hypot(x, y)
+	if distance <= gamepads.Deadzone || distance == 0 {
+		return false, false, false, false
+	}
+	threshold := distance * math.Sin(math.Pi/8)
+	return y < -threshold, y > threshold, x < -threshold, x > threshold
+}
+
+// Values of a not in b.
+func difference(a []int, b []int) []int {
+	var values []int
+	for _, value := range a {
+		found := false
+		for _, other := range b {
+			found = found || value == other
+		}
+		if !found {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,140 @@
+package input_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+	"github.com/tangzero/chip8-emulator/input"
+)
+
+type FakeGamepad struct {
+	Buttons map[input.GamepadButton]bool
+	X, Y    float64
+}
+
+// FakeGamepads is a GamepadSource with gamepads set by the tests.
+type FakeGamepads map[int]*FakeGamepad
+
+func (fake FakeGamepads) GamepadIDs() []int {
+	var ids []int
+	for id := 0; id < 4; id++ {
+		if fake[id] != nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (fake FakeGamepads) ButtonPressed(id int, button input.GamepadButton) bool {
+	return fake[id].Buttons[button]
+}
+
+func (fake FakeGamepads) LeftStick(id int) (float64, float64) {
+	return fake[id].X, fake[id].Y
+}
+
+func NewFakeGamepad(buttons ...input.GamepadButton) *FakeGamepad {
+	gamepad := &FakeGamepad{Buttons: make(map[input.GamepadButton]bool)}
+	for _, button := range buttons {
+		gamepad.Buttons[button] = true
+	}
+	return gamepad
+}
+
+func PressedKeys(gamepads *input.Gamepads) []uint8 {
+	var keys []uint8
+	for key := uint8(0); key < chip8.KeyCount; key++ {
+		if gamepads.Pressed(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestGamepads_Buttons(t *testing.T) {
+	source := FakeGamepads{0: NewFakeGamepad(input.ButtonUp, input.ButtonA)}
+	gamepads := input.NewGamepads(source, input.DefaultGamepad)
+
+	gamepads.Update()
+
+	assert.Equal(t, []uint8{0x2, 0x5}, PressedKeys(gamepads))
+}
+
+func TestGamepads_Stick(t *testing.T) {
+	source := FakeGamepads{0: NewFakeGamepad()}
+	gamepads := input.NewGamepads(source, input.DefaultGamepad)
+
+	source[0].X, source[0].Y = 0.2, -0.1 // inside the deadzone
+	gamepads.Update()
+	assert.Empty(t, PressedKeys(gamepads))
+
+	source[0].X, source[0].Y = 0.9, -0.1
+	gamepads.Update()
+	assert.Equal(t, []uint8{0x6}, PressedKeys(gamepads))
+
+	source[0].X, source[0].Y = -0.6, 0.6 // diagonal
+	gamepads.Update()
+	assert.Equal(t, []uint8{0x4, 0x8}, PressedKeys(gamepads))
+}
+
+func TestGamepads_HotPlug(t *testing.T) {
+	source := FakeGamepads{}
+	gamepads := input.NewGamepads(source, input.DefaultGamepad)
+
+	connected, disconnected := gamepads.Update()
+	assert.Empty(t, connected)
+	assert.Empty(t, disconnected)
+
+	source[1] = NewFakeGamepad(input.ButtonStart)
+	connected, disconnected = gamepads.Update()
+	assert.Equal(t, []int{1}, connected)
+	assert.Empty(t, disconnected)
+	assert.Equal(t, []uint8{0xF}, PressedKeys(gamepads))
+
+	delete(source, 1)
+	connected, disconnected = gamepads.Update()
+	assert.Empty(t, connected)
+	assert.Equal(t, []int{1}, disconnected)
+	assert.Empty(t, PressedKeys(gamepads))
+}
+
+func TestGamepads_MultipleGamepads(t *testing.T) {
+	source := FakeGamepads{
+		0: NewFakeGamepad(input.ButtonLeft),
+		2: NewFakeGamepad(input.ButtonB),
+	}
+	gamepads := input.NewGamepads(source, input.DefaultGamepad)
+
+	gamepads.Update()
+
+	assert.Equal(t, []uint8{0x4, 0xA}, PressedKeys(gamepads))
+}
+
+func TestConfig_GamepadKeymap(t *testing.T) {
+	config := input.Config{
+		Binding: input.Binding{Gamepad: map[string][]string{"5": {"A", "R1"}}},
+		ROMs: map[string]input.Binding{
+			ROMHash(): {Gamepad: map[string][]string{"6": {"B"}}},
+		},
+	}
+
+	keymap, err := config.GamepadKeymap(TestROM)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A", "R1"}, keymap[0x5])
+	assert.Equal(t, []string{"B"}, keymap[0x6])
+	assert.Equal(t, []string{"Up"}, keymap[0x2])
+
+	source := FakeGamepads{0: NewFakeGamepad(input.ButtonR1)}
+	gamepads := input.NewGamepads(source, keymap)
+	gamepads.Update()
+	assert.Equal(t, []uint8{0x3, 0x5}, PressedKeys(gamepads)) // R1 is still bound to 3
+}
+
+func TestConfig_GamepadKeymap_Invalid(t *testing.T) {
+	config := input.Config{Binding: input.Binding{Gamepad: map[string][]string{"5": {"Turbo"}}}}
+
+	_, err := config.GamepadKeymap(TestROM)
+
+	assert.Error(t, err)
+}
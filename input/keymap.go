@@ -0,0 +1,207 @@
+// Package input maps the host keyboard and gamepads to the CHIP-8 keypad.
+// It works with key names, so the bindings can be loaded, combined and
+// tested without a frontend.
+package input
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// Keymap lists the host keys bound to each CHIP-8 key, by name
+// (e.g. "X", "Digit1", "Numpad7", "ArrowUp"). Any of them presses the key.
+// Keyboard keys are physical positions named after a US layout: "Q" is the
+// key left of "W" whatever its label, so AZERTY and Dvorak keyboards get
+// the same keys.
+type Keymap [chip8.KeyCount][]string
+
+// Named keymap presets.
+var Presets = map[string]Keymap{
+	// The 4x4 block on the left of a QWERTY keyboard, shaped like the keypad:
+	//   1 2 3 4      1 2 3 C
+	//   Q W E R  ->  4 5 6 D
+	//   A S D F      7 8 9 E
+	//   Z X C V      A 0 B F
+	"qwerty": {
+		{"X"}, {"Digit1"}, {"Digit2"}, {"Digit3"},
+		{"Q"}, {"W"}, {"E"}, {"A"},
+		{"S"}, {"D"}, {"Z"}, {"C"},
+		{"Digit4"}, {"R"}, {"F"}, {"V"},
+	},
+	// The COSMAC VIP hex keypad: each key is the one with the same hex digit
+	// on a US layout.
+	"vip": {
+		{"Digit0"}, {"Digit1"}, {"Digit2"}, {"Digit3"},
+		{"Digit4"}, {"Digit5"}, {"Digit6"}, {"Digit7"},
+		{"Digit8"}, {"Digit9"}, {"A"}, {"B"},
+		{"C"}, {"D"}, {"E"}, {"F"},
+	},
+	// The numeric keypad digits, so 2, 4, 6 and 8 are the arrows most games
+	// expect, with A-F on the operators.
+	"numpad": {
+		{"Numpad0"}, {"Numpad1"}, {"Numpad2"}, {"Numpad3"},
+		{"Numpad4"}, {"Numpad5"}, {"Numpad6"}, {"Numpad7"},
+		{"Numpad8"}, {"Numpad9"}, {"NumpadDivide"}, {"NumpadMultiply"},
+		{"NumpadSubtract"}, {"NumpadAdd"}, {"NumpadEnter"}, {"NumpadDecimal"},
+	},
+}
+
+const DefaultPreset = "qwerty"
+
+// Names of all keymap presets, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find a keymap preset by its name.
+func PresetByName(name string) (Keymap, bool) {
+	keymap, ok := Presets[strings.ToLower(name)]
+	return keymap, ok
+}
+
+// Whether a CHIP-8 key is pressed, given the state of the host keys.
+func (keymap *Keymap) Pressed(key uint8, down func(name string) bool) bool {
+	for _, name := range keymap[key] {
+		if down(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Binding selects a preset and changes some of its keys and gamepad buttons.
+type Binding struct {
+	Preset  string              `json:"preset,omitempty"`  // keymap preset name
+	Keys    map[string][]string `json:"keys,omitempty"`    // host keys replacing the preset ones, by CHIP-8 hex digit
+	Gamepad map[string][]string `json:"gamepad,omitempty"` // gamepad buttons replacing the default ones, by CHIP-8 hex digit
+}
+
+// Replace the names bound to some CHIP-8 keys, given by hex digit.
+func override(keymap *Keymap, names map[string][]string) error {
+	for digit, bound := range names {
+		key, err := strconv.ParseUint(digit, 16, 8)
+		if err != nil || key >= chip8.KeyCount {
+			return fmt.Errorf("input: invalid CHIP-8 key %q", digit)
+		}
+		keymap[key] = append([]string{}, bound...)
+	}
+	return nil
+}
+
+// Copy a keymap, so changes don't affect the original.
+func (keymap Keymap) clone() Keymap {
+	for key := range keymap {
+		keymap[key] = append([]string{}, keymap[key]...)
+	}
+	return keymap
+}
+
+// Config is the keypad configuration file: a global binding,
+// and bindings for specific ROMs.
+//
+//	{
+//	  "preset": "qwerty",
+//	  "keys": {"5": ["W", "ArrowUp"]},
+//	  "gamepad": {"F": ["Start"]},
+//	  "roms": {"<ROM SHA-256>": {"preset": "numpad"}}
+//	}
+//
+// A ROM binding with a preset replaces the global binding; otherwise its keys
+// and gamepad buttons are applied over the global ones.
+type Config struct {
+	Binding
+	ROMs map[string]Binding `json:"roms,omitempty"` // by lower case hex encoded ROM SHA-256
+}
+
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("input: invalid keymap config: %v", err)
+	}
+	// hashes are matched in lower case
+	roms := make(map[string]Binding, len(config.ROMs))
+	for hash, binding := range config.ROMs {
+		roms[strings.ToLower(hash)] = binding
+	}
+	config.ROMs = roms
+	return config, nil
+}
+
+// Read a config file. A missing file is an empty config.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data)
+}
+
+// Bindings applying to a ROM, in order.
+func (config Config) bindings(rom chip8.ROM) []Binding {
+	hash := rom.Hash()
+	romBinding, ok := config.ROMs[hex.EncodeToString(hash[:])]
+	if !ok {
+		return []Binding{config.Binding}
+	}
+	if romBinding.Preset != "" {
+		return []Binding{romBinding}
+	}
+	return []Binding{config.Binding, romBinding}
+}
+
+// The keymap of a ROM.
+func (config Config) Keymap(rom chip8.ROM) (Keymap, error) {
+	bindings := config.bindings(rom)
+	name := DefaultPreset
+	for _, binding := range bindings {
+		if binding.Preset != "" {
+			name = binding.Preset
+		}
+	}
+	keymap, ok := PresetByName(name)
+	if !ok {
+		return Keymap{}, fmt.Errorf("input: unknown keymap preset %q", name)
+	}
+
+	keymap = keymap.clone()
+	for _, binding := range bindings {
+		if err := override(&keymap, binding.Keys); err != nil {
+			return Keymap{}, err
+		}
+	}
+	return keymap, nil
+}
+
+// The gamepad buttons of a ROM, by name (see GamepadButtons).
+func (config Config) GamepadKeymap(rom chip8.ROM) (Keymap, error) {
+	keymap := DefaultGamepad.clone()
+	for _, binding := range config.bindings(rom) {
+		if err := override(&keymap, binding.Gamepad); err != nil {
+			return Keymap{}, err
+		}
+	}
+	for _, names := range keymap {
+		for _, name := range names {
+			if _, ok := GamepadButtonByName(name); !ok {
+				return Keymap{}, fmt.Errorf("input: unknown gamepad button %q", name)
+			}
+		}
+	}
+	return keymap, nil
+}
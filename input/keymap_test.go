@@ -0,0 +1,105 @@
+package input_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+	"github.com/tangzero/chip8-emulator/input"
+)
+
+var TestROM = chip8.ROM{Data: []byte{0x12, 0x00}}
+
+// Hex encoded SHA-256 of TestROM.
+func ROMHash() string {
+	hash := TestROM.Hash()
+	return hex.EncodeToString(hash[:])
+}
+
+func TestConfig_Keymap_Default(t *testing.T) {
+	keymap, err := input.Config{}.Keymap(TestROM)
+
+	assert.NoError(t, err)
+	assert.Equal(t, input.Presets["qwerty"], keymap)
+}
+
+func TestConfig_Keymap_Overrides(t *testing.T) {
+	config, err := input.ParseConfig([]byte(`{
+		"preset": "VIP",
+		"keys": {"5": ["W", "ArrowUp"], "a": ["Space"]}
+	}`))
+	assert.NoError(t, err)
+
+	keymap, err := config.Keymap(TestROM)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"W", "ArrowUp"}, keymap[0x5])
+	assert.Equal(t, []string{"Space"}, keymap[0xA])
+	assert.Equal(t, []string{"Digit4"}, keymap[0x4])
+	assert.Equal(t, []string{"Digit5"}, input.Presets["vip"][0x5]) // presets are unchanged
+}
+
+func TestConfig_Keymap_ROM(t *testing.T) {
+	config := input.Config{
+		Binding: input.Binding{Keys: map[string][]string{"5": {"W"}}},
+		ROMs: map[string]input.Binding{
+			ROMHash(): {Keys: map[string][]string{"6": {"ArrowRight"}}},
+		},
+	}
+
+	keymap, err := config.Keymap(TestROM)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"W"}, keymap[0x5])
+	assert.Equal(t, []string{"ArrowRight"}, keymap[0x6])
+
+	keymap, err = config.Keymap(chip8.ROM{Data: []byte{0x00, 0xE0}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"E"}, keymap[0x6])
+}
+
+func TestConfig_Keymap_ROMPreset(t *testing.T) {
+	config := input.Config{
+		Binding: input.Binding{Preset: "vip", Keys: map[string][]string{"5": {"W"}}},
+		ROMs: map[string]input.Binding{
+			ROMHash(): {Preset: "numpad"},
+		},
+	}
+
+	keymap, err := config.Keymap(TestROM)
+
+	assert.NoError(t, err)
+	assert.Equal(t, input.Presets["numpad"], keymap)
+}
+
+func TestParseConfig_UpperCaseHash(t *testing.T) {
+	config, err := input.ParseConfig([]byte(`{
+		"roms": {"` + strings.ToUpper(ROMHash()) + `": {"preset": "numpad"}}
+	}`))
+	assert.NoError(t, err)
+
+	keymap, err := config.Keymap(TestROM)
+
+	assert.NoError(t, err)
+	assert.Equal(t, input.Presets["numpad"], keymap)
+}
+
+func TestConfig_Keymap_Invalid(t *testing.T) {
+	_, err := input.Config{Binding: input.Binding{Preset: "dvorak"}}.Keymap(TestROM)
+	assert.Error(t, err)
+
+	_, err = input.Config{Binding: input.Binding{Keys: map[string][]string{"10": {"A"}}}}.Keymap(TestROM)
+	assert.Error(t, err)
+
+	_, err = input.ParseConfig([]byte(`{"preset": 1}`))
+	assert.Error(t, err)
+}
+
+func TestKeymap_Pressed(t *testing.T) {
+	keymap := input.Keymap{0x5: {"W", "ArrowUp"}}
+	down := func(name string) bool { return name == "ArrowUp" }
+
+	assert.True(t, keymap.Pressed(0x5, down))
+	assert.False(t, keymap.Pressed(0x6, down))
+}
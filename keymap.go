@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tangzero/chip8-emulator/chip8"
+	"github.com/tangzero/chip8-emulator/input"
+)
+
+var KeymapPreset = flag.String("keymap", "", "keypad preset, ignoring the keymap file: "+strings.Join(input.PresetNames(), ", "))
+
+// Host keys bound to each CHIP-8 key.
+var KeyMapping [chip8.KeyCount][]ebiten.Key
+
+// Host keys by lower case name.
+var KeyNames = func() map[string]ebiten.Key {
+	names := make(map[string]ebiten.Key)
+	for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
+		if name := key.String(); name != "" {
+			names[strings.ToLower(name)] = key
+		}
+	}
+	return names
+}()
+
+// Find a host key by name. Digits are also accepted without the "Digit" prefix.
+func KeyByName(name string) (ebiten.Key, bool) {
+	name = strings.ToLower(name)
+	if len(name) == 1 && name[0] >= '0' && name[0] <= '9' {
+		name = "digit" + name
+	}
+	key, ok := KeyNames[name]
+	return key, ok
+}
+
+// Bind the keypad and gamepad buttons for a ROM, from the keymap file in
+// the settings directory or the preset given in the command line.
+func LoadKeymap(rom chip8.ROM) {
+	var config input.Config
+	if *KeymapPreset != "" {
+		config.Preset = *KeymapPreset
+	} else if path, err := SettingsPath("keymap.json"); err == nil {
+		if config, err = input.LoadConfig(path); err != nil {
+			log.Println(err)
+		}
+	}
+
+	gamepad, err := config.GamepadKeymap(rom)
+	if err != nil {
+		log.Println(err)
+		gamepad = input.DefaultGamepad
+	}
+	Gamepads.Keymap = gamepad
+	Gamepads.Deadzone = *Deadzone
+
+	keymap, err := config.Keymap(rom)
+	if err != nil {
+		log.Println(err)
+		keymap = input.Presets[input.DefaultPreset]
+	}
+	for index, names := range keymap {
+		KeyMapping[index] = nil
+		for _, name := range names {
+			key, ok := KeyByName(name)
+			if !ok {
+				log.Printf("unknown key: %s", name)
+				continue
+			}
+			KeyMapping[index] = append(KeyMapping[index], key)
+		}
+	}
+}
+
+func KeyPressed(key uint8) bool {
+	if Gamepads.Pressed(key) {
+		return true
+	}
+	for _, hostKey := range KeyMapping[key] {
+		if ebiten.IsKeyPressed(hostKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,17 +3,28 @@
 #include <stdio.h>
 
 // callbacks
+static retro_environment_t retro_environment = NULL;
 static retro_video_refresh_t retro_video_refresh = NULL;
+static retro_audio_sample_batch_t retro_audio_sample_batch = NULL;
 static retro_input_poll_t retro_input_poll = NULL;
 static retro_input_state_t retro_input_state = NULL;
 
+// core options
+static struct retro_variable variables[] = {
+    {"chip8_palette", "Palette; green|amber|white|lcd|octo"},
+    {"chip8_phosphor", "Flicker reduction; disabled|hold|fade|hold and fade"},
+    {"chip8_filter", "Filter; none|scale2x|scale3x|scanlines|lcd|crt"},
+    {NULL, NULL},
+};
+
 // input params
 static unsigned input_port = 0;
 static unsigned input_device = 0;
 
 RETRO_API void retro_set_environment(retro_environment_t cb)
 {
-    // TODO: implement this
+    retro_environment = cb;
+    retro_environment(RETRO_ENVIRONMENT_SET_VARIABLES, variables);
 }
 
 RETRO_API void retro_set_video_refresh(retro_video_refresh_t cb)
@@ -23,12 +34,12 @@ RETRO_API void retro_set_video_refresh(retro_video_refresh_t cb)
 
 RETRO_API void retro_set_audio_sample(retro_audio_sample_t cb)
 {
-    // TODO: implement this
+    // not used: the audio is sent in batches
 }
 
 RETRO_API void retro_set_audio_sample_batch(retro_audio_sample_batch_t cb)
 {
-    // TODO: implement this
+    retro_audio_sample_batch = cb;
 }
 
 RETRO_API void retro_set_input_poll(retro_input_poll_t cb)
@@ -151,6 +162,21 @@ void VideoRefresh(const void *data, unsigned width, unsigned height, size_t pitc
     retro_video_refresh(data, width, height, pitch);
 }
 
+void AudioSampleBatch(const int16_t *data, size_t frames)
+{
+    // the frontend may take fewer frames than given
+    while (frames > 0)
+    {
+        size_t written = retro_audio_sample_batch(data, frames);
+        if (written == 0)
+        {
+            break;
+        }
+        data += written * 2;
+        frames -= written;
+    }
+}
+
 void InputPoll(void)
 {
     retro_input_poll();
@@ -159,4 +185,32 @@ void InputPoll(void)
 int16_t InputState(unsigned id)
 {
     return retro_input_state(0, RETRO_DEVICE_JOYPAD, 0, id);
-}
\ No newline at end of file
+}
+
+const char *GetVariable(const char *key)
+{
+    struct retro_variable variable = {key, NULL};
+    if (!retro_environment(RETRO_ENVIRONMENT_GET_VARIABLE, &variable))
+    {
+        return NULL;
+    }
+    return variable.value;
+}
+
+bool CanDupe(void)
+{
+    bool can_dupe = false;
+    return retro_environment(RETRO_ENVIRONMENT_GET_CAN_DUPE, &can_dupe) && can_dupe;
+}
+
+bool VariablesUpdated(void)
+{
+    bool updated = false;
+    return retro_environment(RETRO_ENVIRONMENT_GET_VARIABLE_UPDATE, &updated) && updated;
+}
+
+void ShowMessage(const char *msg, unsigned frames)
+{
+    struct retro_message message = {msg, frames};
+    retro_environment(RETRO_ENVIRONMENT_SET_MESSAGE, &message);
+}
@@ -1,6 +1,7 @@
 package main
 
 /*
+#include <stdlib.h>
 #include "libretro.h"
 
 typedef struct retro_system_info retro_system_info;
@@ -8,8 +9,13 @@ typedef struct retro_system_av_info retro_system_av_info;
 typedef struct retro_game_info retro_game_info;
 
 void VideoRefresh(const void *data, unsigned width, unsigned height, size_t pitch);
+void AudioSampleBatch(const int16_t *data, size_t frames);
 void InputPoll(void);
 int16_t InputState(unsigned id);
+void ShowMessage(const char *msg, unsigned frames);
+const char *GetVariable(const char *key);
+bool VariablesUpdated(void);
+bool CanDupe(void);
 */
 import "C"
 import (
@@ -17,10 +23,12 @@ import (
 	"image/draw"
 	"io/ioutil"
 	"log"
+	"time"
 	"unsafe"
 
 	"github.com/lanzafame/bobblehat/sense/screen/color"
 	"github.com/tangzero/chip8-emulator/chip8"
+	"github.com/tangzero/chip8-emulator/render"
 )
 
 const (
@@ -40,26 +48,58 @@ const (
 	LastRetroButton  = RetroButtonR
 )
 
+// Flicker reduction core option values
+var PhosphorOptions = map[string]*chip8.Phosphor{
+	"disabled":      nil,
+	"hold":          chip8.NewPhosphor(2, 0),
+	"fade":          chip8.NewPhosphor(0, 0.6),
+	"hold and fade": chip8.NewPhosphor(1, 0.5),
+}
+
 var KeyMapping = map[uint8]uint8{
 	RetroButtonLeft:  0x04,
 	RetroButtonX:     0x05,
 	RetroButtonRight: 0x06,
 }
 
+// Largest enlargement of the filter options.
+const MaxFilterScale = 3
+
+// Audio frames sent on each run, as interleaved 16-bit stereo samples.
+const AudioFramesPerRun = chip8.SampleRate / chip8.FPS
+
+// Post-processing filter core option values
+var FilterOptions = map[string]string{
+	"none":      "none",
+	"scale2x":   "scale2x",
+	"scale3x":   "scale3x",
+	"scanlines": "nearest:3,scanlines",
+	"lcd":       "nearest:3,grid",
+	"crt":       "scale3x,scanlines,bloom",
+}
+
 var BuildVersion string
 var Emulator *chip8.Emulator
-var FrameBuffer *color.RGB565
 var KeysState [16]bool
+var Halted bool
+
+// Video output
+var Renderer *chip8.Renderer
+var Pipeline render.Pipeline
+var Phosphor *chip8.Phosphor
+var FrameBuffer *color.RGB565
+var Redraw bool // the options changed the output of an unchanged frame
+
+// Audio output
+var AudioFrames [AudioFramesPerRun * 2]int16 // stereo frames sent to the frontend
+var Samples [AudioFramesPerRun]int16         // mono samples read from the emulator
 
 //export Initialize
 func Initialize() {
-	playSound := func() {}
-	stopSound := func() {}
-	soundPlayer := func([]byte) (func(), func()) { return playSound, stopSound }
+	Emulator = chip8.NewEmulator(KeyPressed, nil)
+	Renderer = chip8.NewRenderer(chip8.DefaultPalette)
 
-	Emulator = chip8.NewEmulator(KeyPressed, soundPlayer)
-
-	FrameBuffer = color.NewRGB565(Emulator.Display.Rect)
+	FrameBuffer = color.NewRGB565(Emulator.Display.Bounds())
 }
 
 //export Deinitialize
@@ -80,30 +120,81 @@ func GetEmulatorInfo(info *C.retro_system_info) {
 func GetEmulatorAVInfo(info *C.retro_system_av_info) {
 	info.geometry.base_width = chip8.Width
 	info.geometry.base_height = chip8.Height
-	info.geometry.max_width = chip8.Width
-	info.geometry.max_height = chip8.Height
+	info.geometry.max_width = chip8.HiResWidth * MaxFilterScale
+	info.geometry.max_height = chip8.HiResHeight * MaxFilterScale
 	info.geometry.aspect_ratio = 0.0
 	info.timing.fps = chip8.FPS
-	info.timing.sample_rate = 44100
+	info.timing.sample_rate = chip8.SampleRate
 }
 
 //export Reset
 func Reset() {
 	Emulator.Reset()
+	Halted = false
 }
 
 //export Run
 func Run() {
+	if C.VariablesUpdated() {
+		LoadOptions()
+	}
+
 	C.InputPoll()
 	UpdateKeysState()
 
-	Emulator.Update()
+	// the frontend calls Run at the declared chip8.FPS rate
+	if err := Emulator.Advance(time.Second / chip8.FPS); err != nil && !Halted {
+		Halted = true
+		ShowMessage(err.Error() + " - reset to continue")
+	}
+	SendAudio()
+
+	frame := Renderer.Render(Emulator.Display)
+	changed := Renderer.Changed || Redraw
+	if Phosphor != nil && (changed || Phosphor.Fading) {
+		frame = Phosphor.Apply(frame, Renderer.Palette[0])
+		changed = true
+	}
+	Redraw = false
+
+	if !changed {
+		width, height := FrameBuffer.Rect.Dx(), FrameBuffer.Rect.Dy()
+		if C.CanDupe() {
+			C.VideoRefresh(nil, C.uint(width), C.uint(height), C.size_t(FrameBuffer.Stride))
+		} else {
+			C.VideoRefresh(unsafe.Pointer(&FrameBuffer.Pix[0]), C.uint(width), C.uint(height), C.size_t(FrameBuffer.Stride))
+		}
+		return
+	}
+
+	frame = Pipeline.Apply(frame)
+
+	// follow the active resolution and filters
+	if FrameBuffer.Rect != frame.Rect {
+		FrameBuffer = color.NewRGB565(frame.Rect)
+	}
 
 	// convert from RGBA to RGB565
-	draw.Draw(FrameBuffer, Emulator.Display.Rect, Emulator.Display, image.Point{}, draw.Src)
+	draw.Draw(FrameBuffer, frame.Rect, frame, image.Point{}, draw.Src)
 
 	// draw frame
-	C.VideoRefresh(unsafe.Pointer(&FrameBuffer.Pix[0]), chip8.Width, chip8.Height, C.size_t(FrameBuffer.Stride))
+	width, height := frame.Rect.Dx(), frame.Rect.Dy()
+	C.VideoRefresh(unsafe.Pointer(&FrameBuffer.Pix[0]), C.uint(width), C.uint(height), C.size_t(FrameBuffer.Stride))
+}
+
+// Send exactly AudioFramesPerRun frames, so the audio keeps pace with the video.
+// Samples beyond them wait for the next run; missing ones are silent.
+func SendAudio() {
+	n := Emulator.ReadSamples(Samples[:])
+	for index := range Samples {
+		sample := int16(0)
+		if index < n {
+			sample = Samples[index]
+		}
+		AudioFrames[index*2] = sample
+		AudioFrames[index*2+1] = sample
+	}
+	C.AudioSampleBatch((*C.int16_t)(unsafe.Pointer(&AudioFrames[0])), AudioFramesPerRun)
 }
 
 //export LoadGame
@@ -114,9 +205,38 @@ func LoadGame(game *C.retro_game_info) bool {
 		return false
 	}
 	Emulator.LoadROM(chip8.ROM{Data: data})
+	LoadOptions()
 	return true
 }
 
+// Apply the core options set in the frontend.
+func LoadOptions() {
+	Redraw = true
+	if name := GetVariable("chip8_palette"); name != "" {
+		if palette, err := chip8.ParsePalette(name); err != nil {
+			log.Println(err)
+		} else {
+			Renderer.Palette = palette
+		}
+	}
+	if name := GetVariable("chip8_phosphor"); name != "" {
+		Phosphor = PhosphorOptions[name]
+	}
+	if name := GetVariable("chip8_filter"); name != "" {
+		if pipeline, err := render.ParsePipeline(FilterOptions[name]); err != nil {
+			log.Println(err)
+		} else {
+			Pipeline = pipeline
+		}
+	}
+}
+
+func GetVariable(key string) string {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+	return C.GoString(C.GetVariable(ckey))
+}
+
 func UpdateKeysState() {
 	for button := FirstRetroButton; button < LastRetroButton; button++ {
 		key, ok := KeyMapping[button]
@@ -126,6 +246,13 @@ func UpdateKeysState() {
 	}
 }
 
+func ShowMessage(message string) {
+	log.Println(message)
+	msg := C.CString(message)
+	defer C.free(unsafe.Pointer(msg))
+	C.ShowMessage(msg, chip8.FPS*5)
+}
+
 func KeyPressed(key uint8) bool {
 	return KeysState[key]
 }
@@ -1,14 +1,23 @@
 package main
 
 import (
-	"bytes"
 	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
 	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/tangzero/chip8-emulator/chip8"
+	"github.com/tangzero/chip8-emulator/render"
 )
 
 //go:embed test_opcode.ch8
@@ -20,102 +29,249 @@ const (
 	Height      = chip8.Height * ScreenScale
 )
 
+var QuirksProfile = flag.String("quirks", "default", "instruction quirks: "+strings.Join(chip8.QuirkProfileNames(), ", "))
+
+var Seed = flag.Uint64("seed", 0, "random number generator seed (0: time based)")
+
+var InstructionsPerSecond = flag.Int("ips", chip8.DefaultInstructionsPerSecond, "instructions per second")
+var TimerRate = flag.Int("timer-rate", chip8.DefaultTimerRate, "delay and sound timers rate in Hz")
+
+var UnknownOpcodes = flag.String("unknown-opcodes", "halt", "unknown opcodes policy: halt, ignore")
+
+var PaletteName = flag.String("palette", "green", "display palette: "+strings.Join(chip8.PaletteNames(), ", ")+" or #RRGGBB colors, comma separated")
+
+var PhosphorHold = flag.Int("phosphor-hold", 0, "frames an erased pixel stays lit (flicker reduction)")
+var PhosphorDecay = flag.Float64("phosphor-decay", 0, "brightness kept per frame by fading pixels, from 0 to 1 (flicker reduction)")
+
+var ScreenshotScale = flag.Int("screenshot-scale", ScreenScale, "screenshots enlargement (1: native size)")
+var ScreenshotAfter = flag.Int("screenshot-after", 0, "save a screenshot after the given number of frames and exit (0: disabled)")
+
+var Record = flag.String("record", "", "record an animated GIF to the given file until exit")
+var RecordAudio = flag.String("record-audio", "", "record the sound to the given WAV file until exit")
+var RecordScale = flag.Int("record-scale", 2, "recordings enlargement of the 128x64 display")
+
+var Scaling = flag.String("scaling", "fit", "display scaling: fit, integer")
+var Fullscreen = flag.Bool("fullscreen", false, "start in fullscreen")
+
+// Audio flags override the saved audio settings, and are saved with them.
+var Frequency = flag.Float64("frequency", chip8.DefaultFrequency, "buzzer tone in Hz")
+var Waveform = flag.String("waveform", "square", "buzzer waveform: "+strings.Join(chip8.WaveformNames(), ", "))
+var Volume = flag.Float64("volume", chip8.DefaultVolume, "buzzer volume, from 0 to 1")
+var Mute = flag.Bool("mute", false, "mute the sound")
+var LowPass = flag.Float64("low-pass", 0, "low-pass filter cutoff in Hz softening the buzzer (0: disabled)")
+
+var Filters = flag.String("filters", "none", "post-processing filters, comma separated: "+strings.Join(render.FilterNames(), ", "))
+
+// ErrQuit stops the game loop without an error.
+var ErrQuit = errors.New("quit")
+
+var AudioContext = audio.NewContext(chip8.SampleRate)
+
 type State = int
 
 const (
 	LoadingState State = iota
 	RunningState
+	HaltedState
 )
 
-// Keyboard Layout
-// 1 2 3 C
-// 4 5 6 D
-// 7 8 9 E
-// A 0 B F
-var KeyMapping = []ebiten.Key{
-	ebiten.KeyX,
-	ebiten.Key1,
-	ebiten.Key2,
-	ebiten.Key3,
-	ebiten.KeyQ,
-	ebiten.KeyW,
-	ebiten.KeyE,
-	ebiten.KeyA,
-	ebiten.KeyS,
-	ebiten.KeyD,
-	ebiten.KeyZ,
-	ebiten.KeyC,
-	ebiten.Key4,
-	ebiten.KeyR,
-	ebiten.KeyF,
-	ebiten.KeyV,
-}
-
 type GUI struct {
-	State    State
-	Emulator *chip8.Emulator
+	State      State
+	Emulator   *chip8.Emulator
+	Renderer   *chip8.Renderer
+	Phosphor   *chip8.Phosphor // optional flicker filter
+	Frame      *image.RGBA     // last rendered frame
+	Pipeline   render.Pipeline // post-processing filters
+	Texture    *ebiten.Image   // last rendered frame, uploaded to the GPU
+	LastUpdate time.Time
+	Frames     int                // updates since the start
+	Recorder   *chip8.GIFRecorder // active recording
+	RecordPath string
+	Idle       bool
+	Scaling    ScalingMode
+
+	SoundStream  *SoundStream  // samples queued for the audio player
+	SoundPlayer  *audio.Player // plays the sound stream
+	Samples      []int16       // buffer to read the emulator samples
+	FrameSamples []int16       // samples generated in the last update
+
+	AudioSettings      AudioSettings
+	SavedAudioSettings AudioSettings // last loaded or saved settings
+
+	AudioRecorder *chip8.WAVRecorder // active audio recording
+	AudioFile     *os.File
 }
 
 func (gui *GUI) Update() error {
 	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
 		gui.Emulator.Reset()
 	}
-	gui.Emulator.Update()
-	return nil
-}
+	gui.UpdateSaveSlots()
+	gui.UpdateScreenshot()
+	gui.UpdateWindow()
+	gui.UpdateAudioSettings()
+	UpdateGamepads()
+	now := time.Now()
+	elapsed := time.Second / chip8.FPS
+	if !gui.LastUpdate.IsZero() {
+		elapsed = now.Sub(gui.LastUpdate)
+	}
+	gui.LastUpdate = now
 
-func (gui *GUI) Draw(screen *ebiten.Image) {
-	frame := ebiten.NewImageFromImage(gui.Emulator.Display)
-	operation := new(ebiten.DrawImageOptions)
-	operation.GeoM.Scale(ScreenScale, ScreenScale)
-	screen.DrawImage(frame, operation)
-}
+	err := gui.Emulator.Advance(elapsed)
+	gui.UpdateSound()
+	switch {
+	case err != nil && gui.State != HaltedState:
+		log.Println(err)
+		gui.State = HaltedState
+	case err == nil:
+		gui.State = RunningState
+	}
 
-func (gui *GUI) Layout(int, int) (int, int) {
-	return Width, Height
-}
+	if gui.Emulator.Idle != gui.Idle {
+		gui.Idle = gui.Emulator.Idle
+		gui.UpdateTitle()
+	}
+
+	frame := gui.Renderer.Render(gui.Emulator.Display)
+	changed := gui.Renderer.Changed
+	if gui.Phosphor != nil && (changed || gui.Phosphor.Fading) {
+		frame = gui.Phosphor.Apply(frame, gui.Renderer.Palette[0])
+		changed = true
+	}
+	if changed {
+		gui.UploadFrame(gui.Pipeline.Apply(frame))
+	}
 
-func KeyPressed(key uint8) bool {
-	return ebiten.IsKeyPressed(KeyMapping[key])
+	gui.UpdateRecording()
+	gui.Frames++
+	if gui.Frames == *ScreenshotAfter {
+		gui.Screenshot()
+		return ErrQuit
+	}
+	return nil
 }
 
-func SoundPlayer(sound []byte) (func(), func()) {
-	stream, err := wav.DecodeWithSampleRate(chip8.SampleRate, bytes.NewReader(sound))
-	assert(err)
-	player, err := audio.NewContext(chip8.SampleRate).NewPlayer(stream)
-	assert(err)
-	player.SetVolume(0.5)
-	return PlaySound(player), StopSound(player)
+// Copy a new frame to the texture, replacing it when the resolution changes.
+func (gui *GUI) UploadFrame(frame *image.RGBA) {
+	gui.Frame = frame
+	if gui.Texture != nil && gui.Texture.Bounds().Size() != frame.Rect.Size() {
+		gui.Texture.Dispose()
+		gui.Texture = nil
+	}
+	if gui.Texture == nil {
+		gui.Texture = ebiten.NewImage(frame.Rect.Dx(), frame.Rect.Dy())
+	}
+	gui.Texture.ReplacePixels(frame.Pix)
 }
 
-func PlaySound(player *audio.Player) func() {
-	return func() {
-		if player.IsPlaying() {
-			return
-		}
-		player.Play()
+func (gui *GUI) UpdateTitle() {
+	title := "CHIP-8 : " + gui.Emulator.ROM.Name
+	if gui.Idle {
+		title += fmt.Sprintf(" (program idle at 0x%03X)", gui.Emulator.PC)
 	}
+	ebiten.SetWindowTitle(title)
 }
 
-func StopSound(player *audio.Player) func() {
-	return func() {
-		player.Pause()
-		assert(player.Rewind())
+func (gui *GUI) Draw(screen *ebiten.Image) {
+	if gui.Texture == nil {
+		return
+	}
+	// follow the active resolution and filters
+	size := gui.Texture.Bounds().Size()
+	area := FrameLayout(screen.Bounds().Size(), size, gui.Scaling)
+	operation := new(ebiten.DrawImageOptions)
+	operation.GeoM.Scale(float64(area.Dx())/float64(size.X), float64(area.Dy())/float64(size.Y))
+	operation.GeoM.Translate(float64(area.Min.X), float64(area.Min.Y))
+	screen.Fill(color.Black)
+	screen.DrawImage(gui.Texture, operation)
+
+	if gui.State == HaltedState {
+		ebitenutil.DebugPrint(screen, gui.Emulator.Error.Error()+"\npress ESC to reset")
 	}
 }
 
+// The screen follows the window size, in device pixels.
+func (gui *GUI) Layout(outsideWidth int, outsideHeight int) (int, int) {
+	scale := ebiten.DeviceScaleFactor()
+	return int(math.Ceil(float64(outsideWidth) * scale)), int(math.Ceil(float64(outsideHeight) * scale))
+}
+
 func main() {
+	ParseFlags()
 	rom := LoadROM()
 
+	quirks, ok := chip8.QuirksByName(*QuirksProfile)
+	if !ok {
+		log.Fatalf("unknown quirks profile: %s", *QuirksProfile)
+	}
+	palette, err := chip8.ParsePalette(*PaletteName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pipeline, err := render.ParsePipeline(*Filters)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scaling, ok := ScalingModes[*Scaling]
+	if !ok {
+		log.Fatalf("unknown scaling mode: %s", *Scaling)
+	}
+	if *InstructionsPerSecond <= 0 {
+		log.Fatalf("invalid instructions per second: %d", *InstructionsPerSecond)
+	}
+	if *TimerRate <= 0 {
+		log.Fatalf("invalid timer rate: %d", *TimerRate)
+	}
+	policy, ok := chip8.UnknownOpcodePolicies[*UnknownOpcodes]
+	if !ok || policy == chip8.CallbackOnUnknownOpcodes {
+		log.Fatalf("unknown opcodes policy not supported: %s", *UnknownOpcodes)
+	}
+
 	gui := GUI{}
 	gui.State = LoadingState
-	gui.Emulator = chip8.NewEmulator(KeyPressed, SoundPlayer)
+	gui.Emulator = chip8.NewEmulator(KeyPressed, nil)
+	gui.Renderer = chip8.NewRenderer(palette)
+	gui.Pipeline = pipeline
+	gui.Scaling = scaling
+	if *PhosphorHold > 0 || *PhosphorDecay > 0 {
+		gui.Phosphor = chip8.NewPhosphor(*PhosphorHold, *PhosphorDecay)
+	}
+	gui.Emulator.Quirks = quirks
+	gui.Emulator.UnknownOpcodes = policy
+	gui.Emulator.InstructionsPerSecond = *InstructionsPerSecond
+	gui.Emulator.TimerRate = *TimerRate
+	if *Seed != 0 {
+		gui.Emulator.SetSeed(*Seed)
+	}
 	gui.Emulator.LoadROM(rom)
+	LoadKeymap(rom)
 
 	ebiten.SetWindowSize(Width, Height)
-	ebiten.SetWindowTitle("CHIP-8 : " + rom.Name)
+	ebiten.SetWindowResizable(true)
+	ebiten.SetFullscreen(*Fullscreen)
+	gui.UpdateTitle()
 
-	assert(ebiten.RunGame(&gui))
+	gui.StartSound()
+	gui.SavedAudioSettings = LoadAudioSettings()
+	gui.AudioSettings = gui.SavedAudioSettings
+	if err := gui.AudioSettings.ApplyFlags(); err != nil {
+		log.Fatal(err)
+	}
+	gui.ApplyAudioSettings()
+	if *Record != "" {
+		gui.StartRecording(*Record)
+	}
+	if *RecordAudio != "" {
+		gui.StartAudioRecording(*RecordAudio)
+	}
+	err = ebiten.RunGame(&gui)
+	gui.StopRecording()
+	gui.StopAudioRecording()
+	gui.SaveAudioSettings()
+	if err != ErrQuit {
+		assert(err)
+	}
 }
 
 func assert(err error) {
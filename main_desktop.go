@@ -3,26 +3,45 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/tangzero/chip8-emulator/chip8"
 )
 
+func ParseFlags() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [rom]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+// Directory of the loaded ROM, where save states are written.
+func ROMDirectory() string {
+	if flag.NArg() > 0 {
+		return filepath.Dir(flag.Arg(0))
+	}
+	return "."
+}
+
 func LoadROM() chip8.ROM {
 	data := DefaultROM
 	name := "test_opcode"
 
-	if len(os.Args) > 1 {
-		bytes, err := ioutil.ReadFile(os.Args[1])
+	if flag.NArg() > 0 {
+		bytes, err := ioutil.ReadFile(flag.Arg(0))
 		if err != nil {
 			log.Fatal(err)
 		}
 		data = bytes
-		name = strings.Split(path.Base(os.Args[1]), ".")[0]
+		name = strings.Split(path.Base(flag.Arg(0)), ".")[0]
 	}
 
 	return chip8.ROM{Data: data, Name: name}
@@ -2,7 +2,38 @@
 
 package main
 
-import "github.com/tangzero/chip8-emulator/chip8"
+import (
+	"flag"
+	"log"
+	"net/url"
+	"strings"
+	"syscall/js"
+
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// Flags are read from the page query string, e.g. ?quirks=vip
+func ParseFlags() {
+	search := js.Global().Get("location").Get("search").String()
+	query, err := url.ParseQuery(strings.TrimPrefix(search, "?"))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for name, values := range query {
+		if flag.Lookup(name) == nil || len(values) == 0 {
+			continue
+		}
+		if err := flag.Set(name, values[0]); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// Directory of the loaded ROM, where save states are written.
+func ROMDirectory() string {
+	return "."
+}
 
 func LoadROM() chip8.ROM {
 	data := DefaultROM
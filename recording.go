@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// Recording key: F9 starts and stops recording an animated GIF and
+// its WAV sound track next to the ROM.
+var RecordKey = ebiten.KeyF9
+
+func (gui *GUI) UpdateRecording() {
+	if inpututil.IsKeyJustPressed(RecordKey) {
+		if gui.Recorder == nil && gui.AudioRecorder == nil {
+			path := RecordingPath(gui.Emulator.ROM.Name, time.Now())
+			gui.StartRecording(path + ".gif")
+			gui.StartAudioRecording(path + ".wav")
+		} else {
+			gui.StopRecording()
+			gui.StopAudioRecording()
+		}
+	}
+	if gui.Recorder != nil {
+		gui.Recorder.Capture(gui.Emulator.Display)
+	}
+	if gui.AudioRecorder != nil {
+		if err := gui.AudioRecorder.Capture(gui.FrameSamples); err != nil {
+			log.Println(err)
+			gui.StopAudioRecording()
+		}
+	}
+}
+
+func (gui *GUI) StartRecording(path string) {
+	gui.Recorder = chip8.NewGIFRecorder(gui.Renderer.Palette, *RecordScale)
+	gui.RecordPath = path
+	log.Printf("recording to %s", path)
+}
+
+func (gui *GUI) StopRecording() {
+	if gui.Recorder == nil {
+		return
+	}
+	recorder := gui.Recorder
+	gui.Recorder = nil
+
+	file, err := os.Create(gui.RecordPath)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = recorder.Encode(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Println(err)
+		os.Remove(gui.RecordPath)
+		return
+	}
+	log.Printf("recording saved to %s", gui.RecordPath)
+}
+
+func (gui *GUI) StartAudioRecording(path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	recorder, err := chip8.NewWAVRecorder(file, chip8.SampleRate)
+	if err != nil {
+		log.Println(err)
+		file.Close()
+		return
+	}
+	gui.AudioRecorder = recorder
+	gui.AudioFile = file
+	log.Printf("recording sound to %s", path)
+}
+
+func (gui *GUI) StopAudioRecording() {
+	if gui.AudioRecorder == nil {
+		return
+	}
+	recorder, file := gui.AudioRecorder, gui.AudioFile
+	gui.AudioRecorder, gui.AudioFile = nil, nil
+
+	err := recorder.Close()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("sound saved to %s", file.Name())
+}
+
+// Path of a recording without extension.
+func RecordingPath(name string, now time.Time) string {
+	return filepath.Join(ROMDirectory(), fmt.Sprintf("%s-%s", name, now.Format("20060102-150405.000")))
+}
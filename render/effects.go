@@ -0,0 +1,158 @@
+package render
+
+import "image"
+
+const (
+	DefaultScanlinesIntensity = 0.5
+	DefaultGridIntensity      = 0.3
+	DefaultBloomStrength      = 0.6
+)
+
+// Scanlines darkens the last row of every band of Size rows, imitating
+// the gaps between the beam lines of a CRT.
+type Scanlines struct {
+	Size      int         // rows per scanline, at least 2
+	Intensity float64     // 0 keeps the row, 1 turns it black
+	Image     *image.RGBA // last filtered frame
+}
+
+func NewScanlines(size int, intensity float64) *Scanlines {
+	scanlines := new(Scanlines)
+	scanlines.Size = size
+	scanlines.Intensity = intensity
+	return scanlines
+}
+
+func (scanlines *Scanlines) Apply(frame *image.RGBA) *image.RGBA {
+	scanlines.Image = resize(scanlines.Image, frame.Rect)
+	copy(scanlines.Image.Pix, frame.Pix)
+
+	size := scanlines.Size
+	if size < 2 {
+		size = 2
+	}
+	factor := 1 - clamp(scanlines.Intensity)
+	for y := size - 1; y < frame.Rect.Dy(); y += size {
+		row := scanlines.Image.Pix[y*scanlines.Image.Stride : y*scanlines.Image.Stride+frame.Rect.Dx()*4]
+		for x := 0; x < len(row); x += 4 {
+			shade(row[x:x+4], factor)
+		}
+	}
+	return scanlines.Image
+}
+
+// Grid darkens the borders between the cells of Size pixels, imitating
+// the gaps between the pixels of an LCD.
+type Grid struct {
+	Size      int         // pixels per cell, at least 2
+	Intensity float64     // 0 keeps the border, 1 turns it black
+	Image     *image.RGBA // last filtered frame
+}
+
+func NewGrid(size int, intensity float64) *Grid {
+	grid := new(Grid)
+	grid.Size = size
+	grid.Intensity = intensity
+	return grid
+}
+
+func (grid *Grid) Apply(frame *image.RGBA) *image.RGBA {
+	grid.Image = resize(grid.Image, frame.Rect)
+	copy(grid.Image.Pix, frame.Pix)
+
+	size := grid.Size
+	if size < 2 {
+		size = 2
+	}
+	factor := 1 - clamp(grid.Intensity)
+	for y := 0; y < frame.Rect.Dy(); y++ {
+		row := grid.Image.Pix[y*grid.Image.Stride : y*grid.Image.Stride+frame.Rect.Dx()*4]
+		for x := 0; x < frame.Rect.Dx(); x++ {
+			if x%size == size-1 || y%size == size-1 {
+				shade(row[x*4:x*4+4], factor)
+			}
+		}
+	}
+	return grid.Image
+}
+
+// Bloom adds a blurred copy of the frame over itself, so the lit pixels
+// glow into their neighbours like a CRT.
+type Bloom struct {
+	Radius   int         // blur radius in pixels, at least 1
+	Strength float64     // brightness of the added glow
+	Image    *image.RGBA // last filtered frame
+
+	blur []float64 // horizontal blur pass
+}
+
+func NewBloom(radius int, strength float64) *Bloom {
+	bloom := new(Bloom)
+	bloom.Radius = radius
+	bloom.Strength = strength
+	return bloom
+}
+
+func (bloom *Bloom) Apply(frame *image.RGBA) *image.RGBA {
+	bloom.Image = resize(bloom.Image, frame.Rect)
+	width, height := frame.Rect.Dx(), frame.Rect.Dy()
+	if len(bloom.blur) != width*height*3 {
+		bloom.blur = make([]float64, width*height*3)
+	}
+	radius := bloom.Radius
+	if radius < 1 {
+		radius = 1
+	}
+	area := float64(2*radius + 1)
+
+	// separable box blur: rows into the buffer, then columns added to the frame
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for channel := 0; channel < 3; channel++ {
+				sum := 0.0
+				for dx := -radius; dx <= radius; dx++ {
+					sum += float64(frame.Pix[y*frame.Stride+clampIndex(x+dx, width)*4+channel])
+				}
+				bloom.blur[(y*width+x)*3+channel] = sum / area
+			}
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := y*frame.Stride + x*4
+			for channel := 0; channel < 3; channel++ {
+				sum := 0.0
+				for dy := -radius; dy <= radius; dy++ {
+					sum += bloom.blur[(clampIndex(y+dy, height)*width+x)*3+channel]
+				}
+				value := float64(frame.Pix[offset+channel]) + sum/area*bloom.Strength
+				if value > 0xFF {
+					value = 0xFF
+				}
+				bloom.Image.Pix[offset+channel] = uint8(value)
+			}
+			bloom.Image.Pix[offset+3] = frame.Pix[offset+3]
+		}
+	}
+	return bloom.Image
+}
+
+func clamp(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
+
+func clampIndex(index int, size int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= size {
+		return size - 1
+	}
+	return index
+}
@@ -0,0 +1,167 @@
+// Package render implements post-processing filters for the emulator frames:
+// pixel-art upscalers and CRT effects working on image.RGBA, so they run
+// the same in every frontend without a GPU.
+package render
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Filter transforms a frame. The returned image is owned by the filter
+// and reused on the next call.
+type Filter interface {
+	Apply(frame *image.RGBA) *image.RGBA
+}
+
+// Pipeline chains filters, each one applied to the output of the previous one.
+type Pipeline []Filter
+
+func (pipeline Pipeline) Apply(frame *image.RGBA) *image.RGBA {
+	for _, filter := range pipeline {
+		frame = filter.Apply(frame)
+	}
+	return frame
+}
+
+// Scale returns how many times the pipeline enlarges a frame.
+func (pipeline Pipeline) Scale() int {
+	scale := 1
+	for _, filter := range pipeline {
+		if scaler, ok := filter.(interface{ Factor() int }); ok {
+			scale *= scaler.Factor()
+		}
+	}
+	return scale
+}
+
+// Filter constructors by name. The argument is the optional value after
+// the colon in a pipeline spec (0 when omitted) and scale is the enlargement
+// of the filters before it.
+var Filters = map[string]func(argument float64, scale int) Filter{
+	"scale2x": func(float64, int) Filter { return new(Scale2x) },
+	"scale3x": func(float64, int) Filter { return new(Scale3x) },
+	"nearest": func(argument float64, _ int) Filter {
+		if argument < 1 {
+			argument = 2
+		}
+		return NewNearest(int(argument))
+	},
+	"scanlines": func(argument float64, scale int) Filter {
+		if argument == 0 {
+			argument = DefaultScanlinesIntensity
+		}
+		return NewScanlines(scale, argument)
+	},
+	"grid": func(argument float64, scale int) Filter {
+		if argument == 0 {
+			argument = DefaultGridIntensity
+		}
+		return NewGrid(scale, argument)
+	},
+	"bloom": func(argument float64, scale int) Filter {
+		if argument == 0 {
+			argument = DefaultBloomStrength
+		}
+		return NewBloom(scale, argument)
+	},
+}
+
+// Largest factor of the nearest filter, bounding the frames size.
+const MaxNearestFactor = 8
+
+// Largest value accepted for the filters arguments.
+var filterLimits = map[string]float64{"nearest": MaxNearestFactor}
+
+// Filters shading the borders of the upscaled pixels, which need an
+// upscaler before them in a pipeline.
+var upscaledFilters = map[string]bool{"scanlines": true, "grid": true}
+
+// Names of all filters, sorted.
+func FilterNames() []string {
+	names := make([]string, 0, len(Filters))
+	for name := range Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse a comma separated list of filters (e.g. "scale2x,scanlines:0.4,bloom").
+// Each filter takes an optional value after a colon: the factor of nearest
+// (up to MaxNearestFactor), or the intensity of scanlines, grid and bloom.
+// Scanlines and grid must follow an upscaler. An empty spec or "none" is an empty pipeline.
+func ParsePipeline(spec string) (Pipeline, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "none") {
+		return nil, nil
+	}
+
+	var pipeline Pipeline
+	for _, item := range strings.Split(spec, ",") {
+		name, value := strings.TrimSpace(item), ""
+		if index := strings.IndexByte(name, ':'); index >= 0 {
+			name, value = strings.TrimSpace(name[:index]), strings.TrimSpace(name[index+1:])
+		}
+
+		constructor, ok := Filters[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("render: unknown filter %q", name)
+		}
+		if upscaledFilters[strings.ToLower(name)] && pipeline.Scale() < 2 {
+			return nil, fmt.Errorf("render: %s needs an upscaler before it", name)
+		}
+		argument := 0.0
+		if value != "" {
+			var err error
+			argument, err = strconv.ParseFloat(value, 64)
+			if limit, ok := filterLimits[strings.ToLower(name)]; err != nil || !(argument >= 0) || ok && argument > limit {
+				return nil, fmt.Errorf("render: invalid value for %s: %q", name, value)
+			}
+		}
+		pipeline = append(pipeline, constructor(argument, pipeline.Scale()))
+	}
+	return pipeline, nil
+}
+
+// Reuse the image when it has the given bounds or allocate a new one.
+func resize(img *image.RGBA, bounds image.Rectangle) *image.RGBA {
+	if img == nil || img.Rect != bounds {
+		return image.NewRGBA(bounds)
+	}
+	return img
+}
+
+// Pixel at (x, y) as an uint32, clamping the coordinates to the frame.
+func pixel(frame *image.RGBA, x int, y int) uint32 {
+	size := frame.Rect.Size()
+	if x < 0 {
+		x = 0
+	} else if x >= size.X {
+		x = size.X - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= size.Y {
+		y = size.Y - 1
+	}
+	offset := y*frame.Stride + x*4
+	pix := frame.Pix[offset : offset+4]
+	return uint32(pix[0]) | uint32(pix[1])<<8 | uint32(pix[2])<<16 | uint32(pix[3])<<24
+}
+
+func setPixel(frame *image.RGBA, x int, y int, value uint32) {
+	offset := y*frame.Stride + x*4
+	pix := frame.Pix[offset : offset+4]
+	pix[0], pix[1], pix[2], pix[3] = uint8(value), uint8(value>>8), uint8(value>>16), uint8(value>>24)
+}
+
+// Multiply the color channels of a pixel, keeping its alpha.
+func shade(pix []uint8, factor float64) {
+	pix[0] = uint8(float64(pix[0]) * factor)
+	pix[1] = uint8(float64(pix[1]) * factor)
+	pix[2] = uint8(float64(pix[2]) * factor)
+}
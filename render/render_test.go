@@ -0,0 +1,154 @@
+package render_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/render"
+)
+
+var (
+	Off = color.RGBA{0x00, 0x00, 0x00, 0xFF}
+	On  = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+// Frame builds an image from rows of '#' (on) and '.' (off) pixels.
+func Frame(rows ...string) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				frame.Set(x, y, On)
+			} else {
+				frame.Set(x, y, Off)
+			}
+		}
+	}
+	return frame
+}
+
+func TestScale2x_Diagonal(t *testing.T) {
+	frame := Frame(
+		"#.",
+		".#",
+	)
+
+	scaled := new(render.Scale2x).Apply(frame)
+
+	assert.Equal(t, Frame(
+		"##..",
+		"#.#.",
+		".#.#",
+		"..##",
+	), scaled)
+}
+
+func TestScale2x_Flat(t *testing.T) {
+	frame := Frame(
+		"##",
+		"##",
+	)
+
+	scaled := new(render.Scale2x).Apply(frame)
+
+	assert.Equal(t, Frame(
+		"####",
+		"####",
+		"####",
+		"####",
+	), scaled)
+}
+
+func TestScale3x_Size(t *testing.T) {
+	frame := Frame(
+		"#.",
+		".#",
+	)
+
+	scaled := new(render.Scale3x).Apply(frame)
+
+	assert.Equal(t, image.Rect(0, 0, 6, 6), scaled.Rect)
+	assert.Equal(t, On, scaled.At(1, 1))
+	assert.Equal(t, Off, scaled.At(4, 1))
+}
+
+func TestNearest(t *testing.T) {
+	scaled := render.NewNearest(2).Apply(Frame("#."))
+
+	assert.Equal(t, Frame(
+		"##..",
+		"##..",
+	), scaled)
+}
+
+func TestScanlines(t *testing.T) {
+	frame := render.NewScanlines(2, 0.5).Apply(Frame("#", "#", "#", "#"))
+
+	assert.Equal(t, On, frame.At(0, 0))
+	assert.Equal(t, color.RGBA{0x7F, 0x7F, 0x7F, 0xFF}, frame.At(0, 1))
+	assert.Equal(t, On, frame.At(0, 2))
+	assert.Equal(t, color.RGBA{0x7F, 0x7F, 0x7F, 0xFF}, frame.At(0, 3))
+}
+
+func TestGrid(t *testing.T) {
+	frame := render.NewGrid(2, 1).Apply(Frame("##", "##"))
+
+	assert.Equal(t, On, frame.At(0, 0))
+	assert.Equal(t, Off, frame.At(1, 0))
+	assert.Equal(t, Off, frame.At(0, 1))
+}
+
+func TestBloom(t *testing.T) {
+	frame := render.NewBloom(1, 1).Apply(Frame("...", ".#.", "..."))
+
+	assert.Equal(t, On, frame.At(1, 1))
+	glow := frame.RGBAAt(0, 0)
+	assert.Greater(t, glow.R, uint8(0))
+	assert.Less(t, glow.R, uint8(0xFF))
+}
+
+func TestParsePipeline(t *testing.T) {
+	pipeline, err := render.ParsePipeline("scale2x, scanlines:0.4,Grid,bloom")
+
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 4)
+	assert.Equal(t, 2, pipeline.Scale())
+	assert.Equal(t, render.NewScanlines(2, 0.4), pipeline[1])
+	assert.Equal(t, render.NewGrid(2, render.DefaultGridIntensity), pipeline[2])
+
+	frame := pipeline.Apply(Frame("#.", ".#"))
+	assert.Equal(t, image.Rect(0, 0, 4, 4), frame.Rect)
+}
+
+func TestParsePipeline_None(t *testing.T) {
+	pipeline, err := render.ParsePipeline("none")
+
+	assert.NoError(t, err)
+	assert.Empty(t, pipeline)
+	frame := Frame("#")
+	assert.Same(t, frame, pipeline.Apply(frame))
+}
+
+func TestParsePipeline_Invalid(t *testing.T) {
+	_, err := render.ParsePipeline("scale4x")
+	assert.Error(t, err)
+
+	_, err = render.ParsePipeline("scale2x,scanlines:dark")
+	assert.Error(t, err)
+
+	_, err = render.ParsePipeline("nearest:1000")
+	assert.Error(t, err)
+}
+
+func TestParsePipeline_NoUpscaler(t *testing.T) {
+	_, err := render.ParsePipeline("scanlines")
+	assert.Error(t, err)
+
+	_, err = render.ParsePipeline("bloom,grid")
+	assert.Error(t, err)
+
+	_, err = render.ParsePipeline("nearest:1,grid")
+	assert.Error(t, err)
+}
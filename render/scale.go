@@ -0,0 +1,138 @@
+package render
+
+import "image"
+
+// Scale2x is the EPX pixel-art upscaler: it doubles the frame size,
+// smoothing diagonal edges without blurring.
+type Scale2x struct {
+	Image *image.RGBA // last scaled frame
+}
+
+func (scaler *Scale2x) Factor() int {
+	return 2
+}
+
+func (scaler *Scale2x) Apply(frame *image.RGBA) *image.RGBA {
+	size := frame.Rect.Size()
+	scaler.Image = resize(scaler.Image, image.Rect(0, 0, size.X*2, size.Y*2))
+
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			//   A
+			// C P B
+			//   D
+			p := pixel(frame, x, y)
+			a, b := pixel(frame, x, y-1), pixel(frame, x+1, y)
+			c, d := pixel(frame, x-1, y), pixel(frame, x, y+1)
+
+			e0, e1, e2, e3 := p, p, p, p
+			if c == a && c != d && a != b {
+				e0 = a
+			}
+			if a == b && a != c && b != d {
+				e1 = b
+			}
+			if d == c && d != b && c != a {
+				e2 = c
+			}
+			if b == d && b != a && d != c {
+				e3 = d
+			}
+
+			setPixel(scaler.Image, x*2, y*2, e0)
+			setPixel(scaler.Image, x*2+1, y*2, e1)
+			setPixel(scaler.Image, x*2, y*2+1, e2)
+			setPixel(scaler.Image, x*2+1, y*2+1, e3)
+		}
+	}
+	return scaler.Image
+}
+
+// Scale3x is the AdvMAME3x pixel-art upscaler: it triples the frame size,
+// smoothing diagonal edges without blurring.
+type Scale3x struct {
+	Image *image.RGBA // last scaled frame
+}
+
+func (scaler *Scale3x) Factor() int {
+	return 3
+}
+
+func (scaler *Scale3x) Apply(frame *image.RGBA) *image.RGBA {
+	size := frame.Rect.Size()
+	scaler.Image = resize(scaler.Image, image.Rect(0, 0, size.X*3, size.Y*3))
+
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			// A B C
+			// D E F
+			// G H I
+			a, b, c := pixel(frame, x-1, y-1), pixel(frame, x, y-1), pixel(frame, x+1, y-1)
+			d, e, f := pixel(frame, x-1, y), pixel(frame, x, y), pixel(frame, x+1, y)
+			g, h, i := pixel(frame, x-1, y+1), pixel(frame, x, y+1), pixel(frame, x+1, y+1)
+
+			out := [9]uint32{e, e, e, e, e, e, e, e, e}
+			if b != h && d != f {
+				if d == b {
+					out[0] = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					out[1] = b
+				}
+				if b == f {
+					out[2] = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					out[3] = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					out[5] = f
+				}
+				if d == h {
+					out[6] = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					out[7] = h
+				}
+				if h == f {
+					out[8] = f
+				}
+			}
+
+			for index, value := range out {
+				setPixel(scaler.Image, x*3+index%3, y*3+index/3, value)
+			}
+		}
+	}
+	return scaler.Image
+}
+
+// Nearest enlarges the frame by an integer factor, repeating the pixels.
+type Nearest struct {
+	Scale int         // enlargement factor
+	Image *image.RGBA // last scaled frame
+}
+
+func NewNearest(scale int) *Nearest {
+	nearest := new(Nearest)
+	nearest.Scale = scale
+	return nearest
+}
+
+func (nearest *Nearest) Factor() int {
+	return nearest.Scale
+}
+
+func (nearest *Nearest) Apply(frame *image.RGBA) *image.RGBA {
+	size := frame.Rect.Size()
+	nearest.Image = resize(nearest.Image, image.Rect(0, 0, size.X*nearest.Scale, size.Y*nearest.Scale))
+
+	for y := 0; y < nearest.Image.Rect.Dy(); y++ {
+		row := nearest.Image.Pix[y*nearest.Image.Stride : y*nearest.Image.Stride+nearest.Image.Rect.Dx()*4]
+		source := frame.Pix[(y/nearest.Scale)*frame.Stride:]
+		for x := 0; x < len(row); x += 4 {
+			copy(row[x:x+4], source[(x/4/nearest.Scale)*4:])
+		}
+	}
+	return nearest.Image
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Save state slots: F1-F4 load the slot, Shift+F1-F4 save it.
+var SaveSlotKeys = []ebiten.Key{
+	ebiten.KeyF1,
+	ebiten.KeyF2,
+	ebiten.KeyF3,
+	ebiten.KeyF4,
+}
+
+func (gui *GUI) UpdateSaveSlots() {
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	for index, key := range SaveSlotKeys {
+		if !inpututil.IsKeyJustPressed(key) {
+			continue
+		}
+		slot := index + 1
+		if shift {
+			gui.SaveState(slot)
+		} else {
+			gui.LoadState(slot)
+		}
+	}
+}
+
+// Save the slot through a temporary file, so a failed save keeps the previous state.
+func (gui *GUI) SaveState(slot int) {
+	path := SaveStatePath(gui.Emulator.ROM.Name, slot)
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = gui.Emulator.SaveState(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Remove(file.Name())
+		return
+	}
+	log.Printf("state saved to slot %d", slot)
+}
+
+func (gui *GUI) LoadState(slot int) {
+	file, err := os.Open(SaveStatePath(gui.Emulator.ROM.Name, slot))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer file.Close()
+
+	if err := gui.Emulator.LoadState(file); err != nil {
+		log.Println(err)
+		return
+	}
+	gui.State = RunningState
+	log.Printf("state loaded from slot %d", slot)
+}
+
+func SaveStatePath(name string, slot int) string {
+	return filepath.Join(ROMDirectory(), fmt.Sprintf("%s.state%d", name, slot))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Screenshot key: F12 saves the display next to the ROM.
+var ScreenshotKey = ebiten.KeyF12
+
+func (gui *GUI) UpdateScreenshot() {
+	if inpututil.IsKeyJustPressed(ScreenshotKey) {
+		gui.Screenshot()
+	}
+}
+
+func (gui *GUI) Screenshot() {
+	path := ScreenshotPath(gui.Emulator.ROM.Name, time.Now())
+	file, err := os.Create(path)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = gui.Emulator.Screenshot(file, gui.Renderer.Palette, *ScreenshotScale)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Println(err)
+		os.Remove(path)
+		return
+	}
+	log.Printf("screenshot saved to %s", path)
+}
+
+func ScreenshotPath(name string, now time.Time) string {
+	return filepath.Join(ROMDirectory(), fmt.Sprintf("%s-%s.png", name, now.Format("20060102-150405.000")))
+}
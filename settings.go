@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// Directory of the user settings, inside the user configuration directory.
+const SettingsDirectory = "chip8-emulator"
+
+// AudioSettings are the sound preferences kept across sessions.
+type AudioSettings struct {
+	Volume    float64 `json:"volume"`    // from 0 to 1
+	Muted     bool    `json:"muted"`     // silence the output, keeping the volume
+	Frequency float64 `json:"frequency"` // buzzer tone in Hz
+	Waveform  string  `json:"waveform"`  // buzzer tone shape
+	LowPass   float64 `json:"low_pass"`  // low-pass filter cutoff in Hz, 0 disables it
+}
+
+func DefaultAudioSettings() AudioSettings {
+	return AudioSettings{
+		Volume:    chip8.DefaultVolume,
+		Frequency: chip8.DefaultFrequency,
+		Waveform:  "square",
+	}
+}
+
+// Audio keys: F5 and F6 lower and raise the volume, F7 mutes.
+var (
+	VolumeDownKey = ebiten.KeyF5
+	VolumeUpKey   = ebiten.KeyF6
+	MuteKey       = ebiten.KeyF7
+)
+
+const VolumeStep = 0.05
+
+func SettingsPath(name string) (string, error) {
+	directory, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(directory, SettingsDirectory, name), nil
+}
+
+// Check the settings are in range, so they can be applied and saved.
+func (settings AudioSettings) Validate() error {
+	if !(settings.Volume >= 0 && settings.Volume <= 1) {
+		return fmt.Errorf("invalid volume: %g (from 0 to 1)", settings.Volume)
+	}
+	if !(settings.Frequency > 0 && settings.Frequency < chip8.SampleRate/2) {
+		return fmt.Errorf("invalid frequency: %g Hz (from 0 to %d)", settings.Frequency, chip8.SampleRate/2)
+	}
+	if _, ok := chip8.WaveformByName(settings.Waveform); !ok {
+		return fmt.Errorf("unknown waveform: %s", settings.Waveform)
+	}
+	if !(settings.LowPass >= 0) {
+		return fmt.Errorf("invalid low-pass cutoff: %g Hz", settings.LowPass)
+	}
+	return nil
+}
+
+// Read the saved audio settings, the defaults when there are none
+// or they are invalid.
+func LoadAudioSettings() AudioSettings {
+	settings := DefaultAudioSettings()
+	path, err := SettingsPath("audio.json")
+	if err != nil {
+		return settings
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &settings)
+	}
+	if err == nil {
+		err = settings.Validate()
+	}
+	if err != nil {
+		log.Printf("%s: %v", path, err)
+		return DefaultAudioSettings()
+	}
+	return settings
+}
+
+func (settings AudioSettings) Save() error {
+	path, err := SettingsPath("audio.json")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Replace the settings with the audio flags given in the command line.
+func (settings *AudioSettings) ApplyFlags() error {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "volume":
+			settings.Volume = *Volume
+		case "mute":
+			settings.Muted = *Mute
+		case "frequency":
+			settings.Frequency = *Frequency
+		case "waveform":
+			settings.Waveform = *Waveform
+		case "low-pass":
+			settings.LowPass = *LowPass
+		}
+	})
+	return settings.Validate()
+}
+
+// Configure the emulator synthesizer and the audio player.
+func (gui *GUI) ApplyAudioSettings() {
+	waveform, _ := chip8.WaveformByName(gui.AudioSettings.Waveform) // validated
+	gui.Emulator.Sound.Frequency = gui.AudioSettings.Frequency
+	gui.Emulator.Sound.Waveform = waveform
+	gui.Emulator.Sound.Volume = gui.AudioSettings.Volume
+	gui.Emulator.Sound.LowPass = gui.AudioSettings.LowPass
+	if gui.SoundPlayer != nil {
+		volume := 1.0
+		if gui.AudioSettings.Muted {
+			volume = 0
+		}
+		gui.SoundPlayer.SetVolume(volume)
+	}
+}
+
+// Save the audio settings when they changed since the last save.
+func (gui *GUI) SaveAudioSettings() {
+	if gui.AudioSettings == gui.SavedAudioSettings {
+		return
+	}
+	if err := gui.AudioSettings.Save(); err != nil {
+		log.Println(err)
+		return
+	}
+	gui.SavedAudioSettings = gui.AudioSettings
+}
+
+func (gui *GUI) UpdateAudioSettings() {
+	settings := &gui.AudioSettings
+	switch {
+	case inpututil.IsKeyJustPressed(VolumeDownKey):
+		settings.Volume = math.Max(0, math.Round((settings.Volume-VolumeStep)*100)/100)
+		log.Printf("volume %.0f%%", settings.Volume*100)
+	case inpututil.IsKeyJustPressed(VolumeUpKey):
+		settings.Volume = math.Min(1, math.Round((settings.Volume+VolumeStep)*100)/100)
+		log.Printf("volume %.0f%%", settings.Volume*100)
+	case inpututil.IsKeyJustPressed(MuteKey):
+		settings.Muted = !settings.Muted
+		log.Printf("muted: %v", settings.Muted)
+	default:
+		return
+	}
+	gui.ApplyAudioSettings()
+	gui.SaveAudioSettings()
+}
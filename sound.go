@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// Most samples queued for the audio player, 100ms. Older samples are
+// dropped so the sound doesn't lag behind the game.
+const MaxSoundLatency = chip8.SampleRate / 10
+
+// SoundStream passes the samples generated by the emulator to the audio
+// player, which reads them from its own goroutine as 16-bit stereo PCM.
+type SoundStream struct {
+	mutex   sync.Mutex
+	samples []int16
+}
+
+// Queue mono samples for the player.
+func (stream *SoundStream) Write(samples []int16) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	stream.samples = append(stream.samples, samples...)
+	if overflow := len(stream.samples) - MaxSoundLatency; overflow > 0 {
+		stream.samples = stream.samples[:copy(stream.samples, stream.samples[overflow:])]
+	}
+}
+
+// Read the queued samples as 16-bit little-endian stereo frames,
+// padding with silence when there aren't enough.
+func (stream *SoundStream) Read(buffer []byte) (int, error) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	frames := len(buffer) / 4
+	n := 0
+	for ; n < frames && n < len(stream.samples); n++ {
+		sample := uint16(stream.samples[n])
+		buffer[n*4+0], buffer[n*4+1] = uint8(sample), uint8(sample>>8)
+		buffer[n*4+2], buffer[n*4+3] = uint8(sample), uint8(sample>>8)
+	}
+	stream.samples = stream.samples[:copy(stream.samples, stream.samples[n:])]
+	for index := n * 4; index < frames*4; index++ {
+		buffer[index] = 0
+	}
+	return frames * 4, nil
+}
+
+// Move the samples generated by the emulator to the stream.
+// They stay in FrameSamples until the next update, for the audio recorder.
+func (gui *GUI) UpdateSound() {
+	n := gui.Emulator.ReadSamples(gui.Samples) // the buffer holds all the generated samples
+	gui.FrameSamples = gui.Samples[:n]
+	gui.SoundStream.Write(gui.FrameSamples)
+}
+
+func (gui *GUI) StartSound() {
+	gui.SoundStream = new(SoundStream)
+	gui.Samples = make([]int16, chip8.MaxBufferedSamples)
+	player, err := AudioContext.NewPlayer(gui.SoundStream)
+	assert(err)
+	player.Play()
+	gui.SoundPlayer = player
+}
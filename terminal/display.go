@@ -0,0 +1,89 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+// ANSI escape sequences.
+const (
+	EnterScreen    = "\x1b[?1049h\x1b[?25l" // alternate screen, hidden cursor
+	ExitScreen     = "\x1b[0m\x1b[?25h\x1b[?1049l"
+	CursorHome     = "\x1b[H"
+	ClearLine      = "\x1b[0m\x1b[K"
+	ClearScreenEnd = "\x1b[0J"
+	Bell           = "\a"
+)
+
+type Color = color.Color
+
+// Draw the display with upper half blocks, two pixels per character:
+// the top one in the foreground color and the bottom one in the background color.
+func DrawHalfBlocks(output *bufio.Writer, fb *chip8.FrameBuffer, palette []Color) {
+	for y := 0; y < fb.Height; y += 2 {
+		top, bottom := -1, -1 // colors are only set when they change
+		for x := 0; x < fb.Width; x++ {
+			if pixel := int(fb.Pixel(x, y)); pixel != top {
+				top = pixel
+				output.WriteString(Foreground(palette[pixel]))
+			}
+			if pixel := int(fb.Pixel(x, y+1)); pixel != bottom {
+				bottom = pixel
+				output.WriteString(Background(palette[pixel]))
+			}
+			output.WriteString("▀")
+		}
+		output.WriteString(ClearLine + "\r\n")
+	}
+}
+
+// Bit of each pixel of a 2x4 braille cell, indexed by [y][x].
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Draw the display with braille patterns, eight pixels per character.
+// Each character has a single color: the brightest plane combination in its cell.
+func DrawBraille(output *bufio.Writer, fb *chip8.FrameBuffer, palette []Color) {
+	output.WriteString(Background(palette[0]))
+	for y := 0; y < fb.Height; y += 4 {
+		current := -1 // only set when it changes
+		for x := 0; x < fb.Width; x += 2 {
+			pattern, pixel := rune(0), uint8(0)
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if value := fb.Pixel(x+dx, y+dy); value != 0 {
+						pattern |= brailleDots[dy][dx]
+						if value > pixel {
+							pixel = value
+						}
+					}
+				}
+			}
+			if int(pixel) != current {
+				current = int(pixel)
+				output.WriteString(Foreground(palette[pixel]))
+			}
+			output.WriteRune(0x2800 + pattern)
+		}
+		output.WriteString("\r\n")
+	}
+}
+
+func Foreground(c Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+}
+
+func Background(c Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,77 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"bufio"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+var (
+	Off   = color.RGBA{0x00, 0x00, 0x00, 0xFF}
+	On    = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	Plane = color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+	Both  = color.RGBA{0xFF, 0xFF, 0x00, 0xFF}
+)
+
+var TestPalette = []Color{Off, On, Plane, Both}
+
+func Draw(draw func(*bufio.Writer, *chip8.FrameBuffer, []Color), fb *chip8.FrameBuffer) string {
+	var builder strings.Builder
+	output := bufio.NewWriter(&builder)
+	draw(output, fb, TestPalette)
+	output.Flush()
+	return builder.String()
+}
+
+func TestDrawHalfBlocks(t *testing.T) {
+	fb := chip8.NewFrameBuffer(2, 2)
+	fb.Toggle(0, 0, 0) // top left
+	fb.Toggle(0, 1, 1) // bottom right
+
+	expected := Foreground(On) + Background(Off) + "▀" +
+		Foreground(Off) + Background(On) + "▀" +
+		ClearLine + "\r\n"
+	assert.Equal(t, expected, Draw(DrawHalfBlocks, fb))
+}
+
+func TestDrawHalfBlocks_SameColors(t *testing.T) {
+	fb := chip8.NewFrameBuffer(3, 2)
+
+	expected := Foreground(Off) + Background(Off) + "▀▀▀" + ClearLine + "\r\n"
+	assert.Equal(t, expected, Draw(DrawHalfBlocks, fb))
+}
+
+func TestDrawBraille(t *testing.T) {
+	fb := chip8.NewFrameBuffer(4, 4)
+	fb.Toggle(0, 0, 0) // dot 1
+	fb.Toggle(0, 1, 3) // dot 8
+	fb.Toggle(1, 2, 1) // dot 2 of the second cell, plane 2
+
+	expected := Background(Off) +
+		Foreground(On) + string(rune(0x2800+0x01+0x80)) +
+		Foreground(Plane) + string(rune(0x2800+0x02)) + "\r\n"
+	assert.Equal(t, expected, Draw(DrawBraille, fb))
+}
+
+func TestDrawBraille_BrightestColor(t *testing.T) {
+	fb := chip8.NewFrameBuffer(2, 4)
+	fb.Toggle(0, 0, 0)
+	fb.Toggle(0, 1, 1)
+	fb.Toggle(1, 1, 1)
+
+	expected := Background(Off) + Foreground(Both) + string(rune(0x2800+0x01+0x10)) + "\r\n"
+	assert.Equal(t, expected, Draw(DrawBraille, fb))
+}
+
+func TestDrawBraille_Empty(t *testing.T) {
+	fb := chip8.NewFrameBuffer(2, 4)
+
+	expected := Background(Off) + Foreground(Off) + "⠀\r\n"
+	assert.Equal(t, expected, Draw(DrawBraille, fb))
+}
@@ -0,0 +1,218 @@
+//go:build linux || darwin
+
+// Command terminal runs the emulator inside a terminal, for sessions
+// where no window is available (e.g. over SSH).
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tangzero/chip8-emulator/chip8"
+)
+
+var QuirksProfile = flag.String("quirks", "default", "instruction quirks: "+strings.Join(chip8.QuirkProfileNames(), ", "))
+var Seed = flag.Uint64("seed", 0, "random number generator seed (0: time based)")
+var InstructionsPerSecond = flag.Int("ips", chip8.DefaultInstructionsPerSecond, "instructions per second")
+var PaletteName = flag.String("palette", "green", "display palette: "+strings.Join(chip8.PaletteNames(), ", ")+" or #RRGGBB colors, comma separated")
+var Braille = flag.Bool("braille", false, "draw with braille characters (2x4 pixels per character) instead of half blocks")
+var KeyTimeout = flag.Duration("key-timeout", 500*time.Millisecond, "time a key stays pressed after its last repeat, longer than the terminal autorepeat delay (shorter values release taps sooner but make held keys stutter)")
+
+// Terminals only report key presses, repeated while the key is held.
+// A key is released when it doesn't repeat within KeyTimeout, which must
+// cover the delay before the first repeat (usually 250 to 660ms).
+var KeyMapping = map[byte]uint8{
+	'x': 0x0, '1': 0x1, '2': 0x2, '3': 0x3,
+	'q': 0x4, 'w': 0x5, 'e': 0x6, 'a': 0x7,
+	's': 0x8, 'd': 0x9, 'z': 0xA, 'c': 0xB,
+	'4': 0xC, 'r': 0xD, 'f': 0xE, 'v': 0xF,
+}
+
+const (
+	KeyQuit  = 0x03 // Ctrl+C
+	KeyReset = 0x1B // Escape
+)
+
+var (
+	keysMutex sync.Mutex
+	keysTime  [chip8.KeyCount]time.Time // last press of each key
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] rom\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	quirks, ok := chip8.QuirksByName(*QuirksProfile)
+	if !ok {
+		log.Fatalf("unknown quirks profile: %s", *QuirksProfile)
+	}
+	palette, err := chip8.ParsePalette(*PaletteName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *InstructionsPerSecond <= 0 {
+		log.Fatalf("invalid instructions per second: %d", *InstructionsPerSecond)
+	}
+
+	emulator := chip8.NewEmulator(KeyPressed, Buzzer)
+	emulator.Sound.SampleRate = 0 // no audio output, only the bell
+	emulator.Quirks = quirks
+	emulator.InstructionsPerSecond = *InstructionsPerSecond
+	if *Seed != 0 {
+		emulator.SetSeed(*Seed)
+	}
+	emulator.LoadROM(chip8.ROM{Data: data, Name: strings.Split(path.Base(flag.Arg(0)), ".")[0]})
+
+	restore, err := MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(EnterScreen)
+	defer func() {
+		fmt.Print(ExitScreen)
+		restore()
+	}()
+
+	Run(emulator, palette, ReadKeys(os.Stdin))
+}
+
+// Run the emulator at FPS until the quit key is pressed.
+func Run(emulator *chip8.Emulator, palette []Color, commands <-chan byte) {
+	output := bufio.NewWriter(os.Stdout)
+	ticker := time.NewTicker(time.Second / chip8.FPS)
+	defer ticker.Stop()
+
+	var err error
+	var display *chip8.FrameBuffer
+	generation := uint64(0)
+	last := time.Now()
+	for {
+		select {
+		case command := <-commands:
+			switch command {
+			case KeyQuit:
+				return
+			case KeyReset:
+				emulator.Reset()
+				display = nil
+			}
+		case now := <-ticker.C:
+			advanceErr := emulator.Advance(now.Sub(last))
+			last = now
+			if advanceErr != nil && err == nil {
+				display = nil // redraw with the error
+			}
+			err = advanceErr
+
+			if display == emulator.Display && generation == emulator.Display.Generation {
+				continue
+			}
+			display, generation = emulator.Display, emulator.Display.Generation
+
+			output.WriteString(CursorHome)
+			if *Braille {
+				DrawBraille(output, display, palette)
+			} else {
+				DrawHalfBlocks(output, display, palette)
+			}
+			output.WriteString(ClearLine)
+			if err != nil {
+				output.WriteString(err.Error() + " - press ESC to reset")
+			} else {
+				output.WriteString(emulator.ROM.Name + " - ESC to reset, Ctrl+C to quit")
+			}
+			output.WriteString(ClearScreenEnd)
+			output.Flush()
+		}
+	}
+}
+
+// Read the keyboard, recording the keypad presses and sending
+// the other keys to the returned channel.
+func ReadKeys(file *os.File) <-chan byte {
+	commands := make(chan byte, 16)
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			n, err := file.Read(buffer)
+			if err != nil {
+				commands <- KeyQuit
+				return
+			}
+			keys, keyCommands := ParseInput(buffer[:n])
+			now := time.Now()
+			keysMutex.Lock()
+			for _, key := range keys {
+				keysTime[key] = now
+			}
+			keysMutex.Unlock()
+			for _, command := range keyCommands {
+				commands <- command
+			}
+		}
+	}()
+	return commands
+}
+
+// Split the bytes read from the terminal into keypad keys and commands.
+// The escape sequences of the arrows and function keys (CSI and SS3)
+// are skipped, so only a lone escape resets.
+func ParseInput(input []byte) (keys []uint8, commands []byte) {
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == KeyReset && i+1 < len(input) && input[i+1] == '[':
+			// CSI: parameters up to a final byte from @ to ~
+			for i += 2; i < len(input) && (input[i] < 0x40 || input[i] > 0x7E); i++ {
+			}
+		case c == KeyReset && i+1 < len(input) && input[i+1] == 'O':
+			i += 2 // SS3: a single final byte
+		case c == KeyReset || c == KeyQuit:
+			commands = append(commands, c)
+		default:
+			if key, ok := KeyMapping[toLower(c)]; ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, commands
+}
+
+func KeyPressed(key uint8) bool {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	return time.Since(keysTime[key]) < *KeyTimeout
+}
+
+// The buzzer rings the terminal bell each time it starts.
+func Buzzer(on bool) {
+	if on {
+		os.Stdout.WriteString(Bell)
+	}
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInput(t *testing.T) {
+	keys, commands := ParseInput([]byte("1Wv"))
+
+	assert.Equal(t, []uint8{0x1, 0x5, 0xF}, keys)
+	assert.Empty(t, commands)
+}
+
+func TestParseInput_EscapeSequences(t *testing.T) {
+	// up, right, left, down, F1, F5
+	keys, commands := ParseInput([]byte("\x1b[A\x1b[C\x1b[D\x1b[B\x1bOP\x1b[15~"))
+
+	assert.Empty(t, keys)
+	assert.Empty(t, commands)
+
+	keys, _ = ParseInput([]byte("q\x1b[1;5Cw"))
+	assert.Equal(t, []uint8{0x4, 0x5}, keys)
+}
+
+func TestParseInput_Commands(t *testing.T) {
+	_, commands := ParseInput([]byte{KeyReset})
+	assert.Equal(t, []byte{KeyReset}, commands)
+
+	keys, commands := ParseInput([]byte{'x', KeyQuit})
+	assert.Equal(t, []uint8{0x0}, keys)
+	assert.Equal(t, []byte{KeyQuit}, commands)
+}
@@ -0,0 +1,31 @@
+//go:build linux || darwin
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// Put the terminal in raw mode: no echo, no line buffering and no signals,
+// so every key is read as soon as it's pressed. Returns the function
+// restoring the previous mode.
+func MakeRaw(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	previous := *termios
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		unix.IoctlSetTermios(fd, ioctlSetTermios, &previous)
+	}, nil
+}
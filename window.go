@@ -0,0 +1,59 @@
+package main
+
+import (
+	"image"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// How the frame is enlarged to fill the window.
+type ScalingMode int
+
+const (
+	FitScaling     ScalingMode = iota // largest size that fits, keeping the aspect ratio
+	IntegerScaling                    // largest whole multiple that fits, for uniform pixels
+)
+
+var ScalingModes = map[string]ScalingMode{
+	"fit":     FitScaling,
+	"integer": IntegerScaling,
+}
+
+// Window keys: F11 toggles fullscreen, F8 switches between the scaling modes.
+var (
+	FullscreenKey = ebiten.KeyF11
+	ScalingKey    = ebiten.KeyF8
+)
+
+func (gui *GUI) UpdateWindow() {
+	if inpututil.IsKeyJustPressed(FullscreenKey) {
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+	}
+	if inpututil.IsKeyJustPressed(ScalingKey) {
+		if gui.Scaling == FitScaling {
+			gui.Scaling = IntegerScaling
+			log.Println("integer scaling")
+		} else {
+			gui.Scaling = FitScaling
+			log.Println("fit scaling")
+		}
+	}
+}
+
+// Area of the screen where a frame is drawn, centered and letterboxed.
+// Integer scaling falls back to fit when the screen is smaller than the frame.
+func FrameLayout(screen image.Point, frame image.Point, mode ScalingMode) image.Rectangle {
+	if frame.X == 0 || frame.Y == 0 {
+		return image.Rectangle{}
+	}
+	scale := math.Min(float64(screen.X)/float64(frame.X), float64(screen.Y)/float64(frame.Y))
+	if mode == IntegerScaling && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	size := image.Pt(int(float64(frame.X)*scale), int(float64(frame.Y)*scale))
+	min := screen.Sub(size).Div(2)
+	return image.Rectangle{Min: min, Max: min.Add(size)}
+}